@@ -6,6 +6,9 @@ import (
 	"flag"
 	"net/url"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 	"sync"
 	"time"
 )
@@ -50,7 +53,7 @@ func browseHandler(data Data, request *Request, channels *Channels) {
 }
 
 
-func browseURL(rawurl string, bench bool) {
+func browseURL(rawurl string, manifestpath string, compactpath string, bench bool) {
 	var channels Channels
 
 	u := geturl(rawurl)
@@ -61,10 +64,16 @@ func browseURL(rawurl string, bench bool) {
 		panic(err)
 	}
 
-	context, err := words.TokenizeNewContext()
+	var context *words.TokenizeContext
+	if len(compactpath) > 0 {
+		context, err = words.TokenizeNewCompactContext(compactpath)
+	} else {
+		context, err = words.TokenizeNewContextFromManifest(getmanifest(manifestpath))
+	}
 	if err != nil {
 		panic("cannot initialize tokenizer")
 	}
+	go reloadOnHangup(context.GetStore())
 
 	// browse initial url
 	request := Request{*u, context, 0, 0, 0, 0, 0, 0}
@@ -108,12 +117,52 @@ func browseURL(rawurl string, bench bool) {
 }
 
 
-func buildlm(path string) {
+// kill -HUP picks up a rebuilt lm.bin or compact dictionary, requests
+// in progress finish with the lexicon they started with
+func reloadOnHangup(store *words.LexiconStore) {
+	sigch := make(chan os.Signal, 1)
+	signal.Notify(sigch, syscall.SIGHUP)
+	for range sigch {
+		snapshot, err := store.Reload()
+		if err != nil {
+			fmt.Printf("Reload failed: %s\n", err)
+			continue
+		}
+		fmt.Printf("Reloaded lexicon generation %d\n", snapshot.Generation)
+	}
+}
+
+
+func getmanifest(manifestpath string) *words.Manifest {
+	if len(manifestpath) == 0 {
+		return words.DefaultManifest()
+	}
+	m, err := words.ReadManifest(manifestpath)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+
+func buildlm(path string, manifestpath string, unknown string) {
+	m := getmanifest(manifestpath)
+	if len(unknown) > 0 {
+		m.Policy = unknown
+	}
+
 	dict := words.Dictionary{}
-	err := dict.ReadXML()
+	dict.Progress = func(source string, read int64, total int64, entries int) {
+		fmt.Printf("%s %d/%d bytes %d entries\n", source, read, total, entries)
+	}
+	summary, err := dict.ReadManifest(m)
 	if err != nil {
 		panic(err)
 	}
+	fmt.Printf("%s\n", summary.String())
+	for _, e := range summary.Errors {
+		fmt.Printf("%s\n", e)
+	}
 	err = dict.WriteBinary(path)
 	if err != nil {
 		panic(err)
@@ -121,23 +170,145 @@ func buildlm(path string) {
 }
 
 
+func buildcompact(lmpath string, path string) {
+	err := readlm(lmpath).WriteCompact(path)
+	if err != nil {
+		panic(err)
+	}
+}
+
+
+func buildfreq(lmpath string, countsdir string, path string) {
+	freq := words.NewFrequencies()
+	err := freq.ReadCounts(countsdir, readlm(lmpath))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%d forms %d lemmas %d occurrences\n", len(freq.Forms), len(freq.Lemmas), freq.Total)
+	err = freq.Write(path)
+	if err != nil {
+		panic(err)
+	}
+}
+
+
+func match(lmpath string, pattern string, filterspec string) {
+	filter, err := words.ParseFilter(filterspec)
+	if err != nil {
+		panic(err)
+	}
+	matched, err := readlm(lmpath).Match(pattern, filter)
+	if err != nil {
+		panic(err)
+	}
+	for _, word := range matched {
+		fmt.Printf("%s\n", word.Description())
+	}
+}
+
+
+func export(lmpath string, path string, format string, langname string) {
+	lang, err := words.ParseLanguage(langname)
+	if err != nil {
+		panic(err)
+	}
+	err = words.ExportFile(readlm(lmpath), path, format, lang)
+	if err != nil {
+		panic(err)
+	}
+}
+
+
+func readlm(lmpath string) *words.Dictionary {
+	dict := words.Dictionary{}
+	err := dict.ReadBinary(lmpath)
+	if err != nil {
+		panic(err)
+	}
+	return &dict
+}
+
+
+func stats(lmpath string) {
+	words.NewStats(readlm(lmpath)).Write(os.Stdout)
+}
+
+
+func diff(frompath string, topath string) {
+	words.NewDiff(readlm(frompath), readlm(topath)).Write(os.Stdout)
+}
+
+
 func main() {
 
 	var rawurl string
 	var lmpath string
+	var manifestpath string
+	var unknown string
+	var compactpath string
+	var buildcompactpath string
+	var buildfreqpath string
+	var pattern string
+	var filterspec string
+	var exportpath string
+	var exportformat string
+	var exportlang string
+	var statspath string
+	var diffpath string
 	var bench bool
 
 	flag.StringVar(&rawurl, "url", "", "url to search")
 	flag.StringVar(&lmpath, "buildlm", "", "build lm")
+	flag.StringVar(&manifestpath, "manifest", "", "dictionary manifest")
+	flag.StringVar(&unknown, "unknown", "", "unknown dictionary attributes: fail, skip or map, overrides manifest")
+	flag.StringVar(&compactpath, "compact", "", "use compact dictionary")
+	flag.StringVar(&buildcompactpath, "buildcompact", "", "build compact dictionary from the manifest binary")
+	flag.StringVar(&buildfreqpath, "buildfreq", "", "build word frequencies from lmoutput counts with the manifest binary")
+	flag.StringVar(&pattern, "match", "", "print the forms of the manifest binary matching a glob or a ^regexp")
+	flag.StringVar(&filterspec, "filter", "", "restrict -match to variants like V+P3s or N:fp")
+	flag.StringVar(&exportpath, "export", "", "export the manifest binary grouped by lemma")
+	flag.StringVar(&exportformat, "format", "", "-export format: xml, delaf, jsonl or csv, guessed from the path when empty")
+	flag.StringVar(&exportlang, "lang", "", "restrict -export to a language: fr or en")
+	flag.StringVar(&statspath, "stats", "", "print the statistics of a compiled lm.bin")
+	flag.StringVar(&diffpath, "diff", "", "print the forms and variants changed from this lm.bin to the manifest binary")
 	flag.BoolVar(&bench, "bench", false, "output benchmarks")
 	flag.Parse()
 
 	if len(rawurl) > 0 {
-		browseURL(rawurl, bench)
+		browseURL(rawurl, manifestpath, compactpath, bench)
 	}
 
 	if len(lmpath) > 0 {
-		buildlm(lmpath)
+		buildlm(lmpath, manifestpath, unknown)
+	}
+
+	if len(buildcompactpath) > 0 {
+		m := getmanifest(manifestpath)
+		buildcompact(m.Resolve(m.Binary), buildcompactpath)
+	}
+
+	if len(buildfreqpath) > 0 {
+		m := getmanifest(manifestpath)
+		buildfreq(m.Resolve(m.Binary), "lmoutput", buildfreqpath)
+	}
+
+	if len(pattern) > 0 {
+		m := getmanifest(manifestpath)
+		match(m.Resolve(m.Binary), pattern, filterspec)
+	}
+
+	if len(exportpath) > 0 {
+		m := getmanifest(manifestpath)
+		export(m.Resolve(m.Binary), exportpath, exportformat, exportlang)
+	}
+
+	if len(statspath) > 0 {
+		stats(statspath)
+	}
+
+	if len(diffpath) > 0 {
+		m := getmanifest(manifestpath)
+		diff(diffpath, m.Resolve(m.Binary))
 	}
 
 }
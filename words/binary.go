@@ -0,0 +1,337 @@
+package words
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// lm.bin format versions
+const (
+	BinaryVersionLegacy = 1 // headerless records without lemma
+	BinaryVersionLemma  = 2 // header, lemma per variant and crc32 trailer
+	BinaryVersion       = BinaryVersionLemma
+)
+
+const maxRecordLen = 1 << 20
+
+var BinaryMagic = [4]byte{'B', 'B', 'L', 'M'}
+
+var (
+	ErrBinaryVersion  = errors.New("unsupported lm.bin version")
+	ErrBinaryChecksum = errors.New("lm.bin checksum mismatch")
+	ErrBinaryCorrupt  = errors.New("corrupt lm.bin")
+)
+
+type BinaryHeader struct {
+	Version   uint32
+	BuildTime time.Time
+	Sources   []string
+	Words     int64
+	Variants  int64
+}
+
+func (h *BinaryHeader) String() string {
+	return fmt.Sprintf("lm.bin v%d built %s words %d variants %d sources %v",
+		h.Version, h.BuildTime.Format(time.RFC3339), h.Words, h.Variants, h.Sources)
+}
+
+func writeBinaryString(w io.Writer, s string) {
+	binary.Write(w, binary.LittleEndian, int32(len(s)))
+	binary.Write(w, binary.LittleEndian, []byte(s))
+}
+
+func readBinaryString(r io.Reader) (s string, err error) {
+	var l int32
+	err = binary.Read(r, binary.LittleEndian, &l)
+	if err != nil {
+		return
+	}
+	if l < 0 || l > maxRecordLen {
+		err = fmt.Errorf("bad string length %d", l)
+		return
+	}
+	b := make([]byte, l)
+	_, err = io.ReadFull(r, b)
+	s = string(b)
+	return
+}
+
+func (h *BinaryHeader) Write(w io.Writer) {
+	binary.Write(w, binary.LittleEndian, BinaryMagic)
+	binary.Write(w, binary.LittleEndian, h.Version)
+	binary.Write(w, binary.LittleEndian, h.BuildTime.Unix())
+	binary.Write(w, binary.LittleEndian, int32(len(h.Sources)))
+	for _, source := range h.Sources {
+		writeBinaryString(w, source)
+	}
+	binary.Write(w, binary.LittleEndian, h.Words)
+	binary.Write(w, binary.LittleEndian, h.Variants)
+}
+
+// reads the header following the magic number
+func (h *BinaryHeader) Read(r io.Reader) (err error) {
+	err = binary.Read(r, binary.LittleEndian, &h.Version)
+	if err != nil {
+		return
+	}
+	if h.Version <= BinaryVersionLegacy || h.Version > BinaryVersion {
+		return fmt.Errorf("%w %d", ErrBinaryVersion, h.Version)
+	}
+
+	var buildTime int64
+	err = binary.Read(r, binary.LittleEndian, &buildTime)
+	if err != nil {
+		return
+	}
+	h.BuildTime = time.Unix(buildTime, 0)
+
+	var n int32
+	err = binary.Read(r, binary.LittleEndian, &n)
+	if err != nil {
+		return
+	}
+	if n < 0 || n > maxRecordLen {
+		return fmt.Errorf("bad source count %d", n)
+	}
+	h.Sources = nil
+	for i := int32(0); i < n; i++ {
+		var source string
+		source, err = readBinaryString(r)
+		if err != nil {
+			return
+		}
+		h.Sources = append(h.Sources, source)
+	}
+
+	err = binary.Read(r, binary.LittleEndian, &h.Words)
+	if err != nil {
+		return
+	}
+	err = binary.Read(r, binary.LittleEndian, &h.Variants)
+	return
+}
+
+// SOURCE_DATE_EPOCH fixes the build time, words are written in rune
+// order so the same sources give the same lm.bin
+func binaryBuildTime() time.Time {
+	epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64)
+	if err != nil {
+		return time.Now()
+	}
+	return time.Unix(epoch, 0)
+}
+
+func (dict *Dictionary) WriteBinary(path string) (err error) {
+	var words []*Word
+
+	dict.Each(WalkOptions{}, func(word *Word) bool {
+		words = append(words, word)
+		return true
+	})
+
+	header := BinaryHeader{}
+	header.Version = BinaryVersion
+	header.BuildTime = binaryBuildTime()
+	header.Sources = dict.Sources
+	header.Words = int64(len(words))
+	for _, word := range words {
+		header.Variants += int64(len(word.Variants))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	crc := crc32.NewIEEE()
+	w := io.MultiWriter(bw, crc)
+
+	header.Write(w)
+	for _, word := range words {
+		word.Write(w)
+	}
+	binary.Write(bw, binary.LittleEndian, crc.Sum32())
+
+	err = bw.Flush()
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// reads lm.bin header without loading words, legacy files have
+// a zero header with BinaryVersionLegacy
+func ReadBinaryHeader(path string) (header BinaryHeader, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	legacy, err := binaryIsLegacy(r)
+	if err != nil {
+		return
+	}
+	if legacy {
+		header.Version = BinaryVersionLegacy
+		return
+	}
+	r.Discard(len(BinaryMagic))
+	err = header.Read(r)
+	if err != nil {
+		err = fmt.Errorf("%s: %w", path, err)
+	}
+	return
+}
+
+func binaryIsLegacy(r *bufio.Reader) (bool, error) {
+	magic, err := r.Peek(len(BinaryMagic))
+	if err == io.EOF && len(magic) == 0 {
+		// empty legacy dictionary
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrBinaryCorrupt, err)
+	}
+	if string(magic) == string(BinaryMagic[:]) {
+		return false, nil
+	}
+
+	// a legacy file starts with the length and letters of a form
+	l := int32(binary.LittleEndian.Uint32(magic))
+	if l <= 0 || l > maxRecordLen {
+		return false, fmt.Errorf("%w: bad magic or legacy word length %d", ErrBinaryCorrupt, l)
+	}
+	if l <= 256 {
+		record, err := r.Peek(4 + int(l))
+		if err != nil || !utf8.Valid(record[4:]) {
+			return false, fmt.Errorf("%w: bad magic or legacy first word", ErrBinaryCorrupt)
+		}
+	}
+	return true, nil
+}
+
+func (dict *Dictionary) ReadBinary(path string) (err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReaderSize(f, 1024*1024)
+	legacy, err := binaryIsLegacy(r)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if legacy {
+		err = dict.readBinaryLegacy(r)
+	} else {
+		err = dict.readBinary(r)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return
+}
+
+func (dict *Dictionary) readBinaryLegacy(r *bufio.Reader) error {
+	header := BinaryHeader{}
+	header.Version = BinaryVersionLegacy
+
+	// as for readBinary, the dictionary is unchanged by a corrupt file
+	var words []Word
+	var forms []string
+	for {
+		// the file may only end between two records
+		if _, err := r.Peek(1); err == io.EOF {
+			break
+		}
+		word := Word{}
+		letters, err := word.ReadFormat(r, BinaryVersionLegacy)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return fmt.Errorf("%w: legacy record %d: %s", ErrBinaryCorrupt, header.Words, err)
+		}
+		words = append(words, word)
+		forms = append(forms, letters)
+		header.Words++
+		header.Variants += int64(len(word.Variants))
+	}
+
+	for i := range words {
+		dict.AddWord(forms[i], &words[i])
+	}
+	dict.Header = &header
+	return nil
+}
+
+func (dict *Dictionary) readBinary(br *bufio.Reader) (err error) {
+	crc := crc32.NewIEEE()
+	r := io.TeeReader(br, crc)
+
+	var magic [4]byte
+	_, err = io.ReadFull(r, magic[:])
+	if err != nil {
+		return
+	}
+
+	header := BinaryHeader{}
+	err = header.Read(r)
+	if err != nil {
+		if errors.Is(err, ErrBinaryVersion) {
+			return err
+		}
+		return fmt.Errorf("%w: header: %s", ErrBinaryCorrupt, err)
+	}
+
+	// read every record before touching the dictionary so that
+	// a corrupt file leaves it unchanged
+	var words []Word
+	var forms []string
+	var variants int64
+	for i := int64(0); i < header.Words; i++ {
+		word := Word{}
+		form, err := word.ReadFormat(r, header.Version)
+		if err != nil {
+			return fmt.Errorf("%w: record %d of %d: %s", ErrBinaryCorrupt, i, header.Words, err)
+		}
+		words = append(words, word)
+		forms = append(forms, form)
+		variants += int64(len(word.Variants))
+	}
+	if variants != header.Variants {
+		return fmt.Errorf("%w: %d variants, header says %d", ErrBinaryCorrupt, variants, header.Variants)
+	}
+
+	var sum uint32
+	err = binary.Read(br, binary.LittleEndian, &sum)
+	if err != nil {
+		return fmt.Errorf("%w: missing checksum: %s", ErrBinaryCorrupt, err)
+	}
+	if sum != crc.Sum32() {
+		return fmt.Errorf("%w: %08x, computed %08x", ErrBinaryChecksum, sum, crc.Sum32())
+	}
+	if _, err = br.ReadByte(); err != io.EOF {
+		return fmt.Errorf("%w: trailing data after checksum", ErrBinaryCorrupt)
+	}
+
+	for i := range words {
+		dict.AddWord(forms[i], &words[i])
+	}
+	dict.Sources = header.Sources
+	dict.Header = &header
+	return nil
+}
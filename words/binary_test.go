@@ -0,0 +1,164 @@
+package words
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"testing"
+)
+
+func TestBinaryHeader(t *testing.T) {
+	dict := GetLemmaDictionary()
+	dict.Sources = []string{"dela-fr-test.dic.xml"}
+
+	err := dict.WriteBinary("test.bin")
+	if err != nil {
+		t.Fatalf("cannot write binary %s", err)
+	}
+
+	header, err := ReadBinaryHeader("test.bin")
+	if err != nil {
+		t.Fatalf("cannot read header %s", err)
+	}
+	if header.Version != BinaryVersion {
+		t.Errorf("bad version %d", header.Version)
+	}
+	if header.Words != 4 || header.Variants != 4 {
+		t.Errorf("bad counts %s", header.String())
+	}
+	if len(header.Sources) != 1 || header.Sources[0] != "dela-fr-test.dic.xml" {
+		t.Errorf("bad sources %v", header.Sources)
+	}
+
+	readdict := Dictionary{}
+	err = readdict.ReadBinary("test.bin")
+	if err != nil {
+		t.Fatalf("cannot read binary %s", err)
+	}
+	if readdict.Header == nil || readdict.Header.Words != 4 {
+		t.Errorf("header not set on dictionary")
+	}
+}
+
+func TestBinaryLegacy(t *testing.T) {
+	var buf bytes.Buffer
+
+	// baseline layout: no header, no lemma, no checksum
+	for _, form := range []string{"test", "banane"} {
+		binary.Write(&buf, binary.LittleEndian, int32(len(form)))
+		buf.WriteString(form)
+		binary.Write(&buf, binary.LittleEndian, int32(1))
+		buf.Write([]byte{NOUN, FRENCH, 0, 0, 0, MALE, SINGULAR, 0})
+	}
+	err := ioutil.WriteFile("test.bin", buf.Bytes(), 0666)
+	if err != nil {
+		t.Fatalf("cannot write legacy binary %s", err)
+	}
+
+	dict := Dictionary{}
+	err = dict.ReadBinary("test.bin")
+	if err != nil {
+		t.Fatalf("cannot read legacy binary %s", err)
+	}
+	word, _ := dict.FindWord("banane")
+	if word == nil || !word.Tagged(NOUN) {
+		t.Errorf("banane not found")
+	}
+	if dict.Header.Version != BinaryVersionLegacy {
+		t.Errorf("bad legacy version %d", dict.Header.Version)
+	}
+
+	// cut right after the letters of the second record
+	ioutil.WriteFile("test.bin", buf.Bytes()[:4+4+4+8+4+6], 0666)
+	readdict := Dictionary{}
+	err = readdict.ReadBinary("test.bin")
+	if !errors.Is(err, ErrBinaryCorrupt) {
+		t.Errorf("truncated legacy binary not detected: %v", err)
+	}
+	if word, _ := readdict.FindWord("test"); word != nil {
+		t.Errorf("truncated legacy binary partially loaded")
+	}
+
+	// neither lm.bin nor legacy
+	for _, garbage := range []string{"\xff\xff\xff\xffgarbage", "\x05\x00\x00\x00\xff\xfe\xfd\xfc\xfb"} {
+		ioutil.WriteFile("test.bin", []byte(garbage), 0666)
+		err = readdict.ReadBinary("test.bin")
+		if !errors.Is(err, ErrBinaryCorrupt) {
+			t.Errorf("garbage %q not detected: %v", garbage, err)
+		}
+		if _, err = ReadBinaryHeader("test.bin"); !errors.Is(err, ErrBinaryCorrupt) {
+			t.Errorf("garbage header %q not detected: %v", garbage, err)
+		}
+	}
+}
+
+func TestBinaryCorrupt(t *testing.T) {
+	dict := GetLemmaDictionary()
+	err := dict.WriteBinary("test.bin")
+	if err != nil {
+		t.Fatalf("cannot write binary %s", err)
+	}
+
+	content, err := ioutil.ReadFile("test.bin")
+	if err != nil {
+		t.Fatalf("cannot read binary %s", err)
+	}
+
+	// flip a byte in the last record
+	corrupt := append([]byte{}, content...)
+	corrupt[len(corrupt)-6] ^= 0xff
+	ioutil.WriteFile("test.bin", corrupt, 0666)
+
+	readdict := Dictionary{}
+	err = readdict.ReadBinary("test.bin")
+	if !errors.Is(err, ErrBinaryChecksum) {
+		t.Errorf("corrupt binary not detected: %v", err)
+	}
+	if word, _ := readdict.FindWord("chien"); word != nil {
+		t.Errorf("corrupt binary partially loaded")
+	}
+
+	// truncated file
+	ioutil.WriteFile("test.bin", content[:len(content)/2], 0666)
+	err = readdict.ReadBinary("test.bin")
+	if !errors.Is(err, ErrBinaryCorrupt) {
+		t.Errorf("truncated binary not detected: %v", err)
+	}
+
+	// future version
+	future := append([]byte{}, content...)
+	binary.LittleEndian.PutUint32(future[4:], BinaryVersion+1)
+	ioutil.WriteFile("test.bin", future, 0666)
+	err = readdict.ReadBinary("test.bin")
+	if !errors.Is(err, ErrBinaryVersion) {
+		t.Errorf("future version not detected: %v", err)
+	}
+}
+
+func TestBinaryReproducible(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+
+	var outputs [][]byte
+	for i := 0; i < 2; i++ {
+		dict := GetCompactTestDictionary()
+		dict.Sources = []string{"dela-fr-test.dic.xml"}
+		err := dict.WriteBinary("test.bin")
+		if err != nil {
+			t.Fatalf("cannot write binary %s", err)
+		}
+		output, err := ioutil.ReadFile("test.bin")
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs = append(outputs, output)
+	}
+	if !bytes.Equal(outputs[0], outputs[1]) {
+		t.Errorf("binary output differs between builds")
+	}
+
+	header, err := ReadBinaryHeader("test.bin")
+	if err != nil || header.BuildTime.Unix() != 1700000000 {
+		t.Errorf("SOURCE_DATE_EPOCH not used %v", err)
+	}
+}
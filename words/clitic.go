@@ -0,0 +1,136 @@
+package words
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// french pronouns postposed to a verb with a hyphen as in dit-il or
+// donne-le-moi, their tokens are IsPostposed
+var clitics = map[string][]WordVariant{
+	"je":    {{Tag: PRONOUN, Person: 1, Number: SINGULAR, Lemma: "je"}},
+	"tu":    {{Tag: PRONOUN, Person: 2, Number: SINGULAR, Lemma: "tu"}},
+	"il":    {{Tag: PRONOUN, Person: 3, Gender: MALE, Number: SINGULAR, Lemma: "il"}},
+	"elle":  {{Tag: PRONOUN, Person: 3, Gender: FEMALE, Number: SINGULAR, Lemma: "elle"}},
+	"on":    {{Tag: PRONOUN, Person: 3, Number: SINGULAR, Lemma: "on"}},
+	"nous":  {{Tag: PRONOUN, Person: 1, Number: PLURAL, Lemma: "nous"}},
+	"vous":  {{Tag: PRONOUN, Person: 2, Number: PLURAL, Lemma: "vous"}},
+	"ils":   {{Tag: PRONOUN, Person: 3, Gender: MALE, Number: PLURAL, Lemma: "il"}},
+	"elles": {{Tag: PRONOUN, Person: 3, Gender: FEMALE, Number: PLURAL, Lemma: "elle"}},
+	"le":    {{Tag: PRONOUN, Person: 3, Gender: MALE, Number: SINGULAR, Lemma: "le"}},
+	"la":    {{Tag: PRONOUN, Person: 3, Gender: FEMALE, Number: SINGULAR, Lemma: "le"}},
+	"les":   {{Tag: PRONOUN, Person: 3, Number: PLURAL, Lemma: "le"}},
+	"lui":   {{Tag: PRONOUN, Person: 3, Number: SINGULAR, Lemma: "lui"}},
+	"leur":  {{Tag: PRONOUN, Person: 3, Number: PLURAL, Lemma: "leur"}},
+	"moi":   {{Tag: PRONOUN, Person: 1, Number: SINGULAR, Lemma: "moi"}},
+	"toi":   {{Tag: PRONOUN, Person: 2, Number: SINGULAR, Lemma: "toi"}},
+	"en":    {{Tag: PRONOUN, Lemma: "en"}},
+	"y":     {{Tag: PRONOUN, Lemma: "y"}},
+	"ce":    {{Tag: PRONOUN, Subcat: DEMONSTRATIVE, Lemma: "ce"}},
+
+	// t is te before en and y as in va-t-en, t' as in va-t'en
+	"t":  {{Tag: PRONOUN, Person: 2, Number: SINGULAR, Lemma: "te"}},
+	"t'": {{Tag: PRONOUN, Person: 2, Number: SINGULAR, Flags: ELIDED, Lemma: "te"}},
+}
+
+// subjects taking a euphonic t after a verb ending with a vowel, a-t-il
+var euphonicSubjects = map[string]bool{"il": true, "elle": true, "on": true}
+
+func newCliticWord(form string, variants []WordVariant) *Word {
+	word := Word{}
+	word.form = form
+	for _, v := range variants {
+		v.Language = FRENCH
+		word.Variants = append(word.Variants, v)
+	}
+	return &word
+}
+
+var cliticWords = func() map[string]*Word {
+	words := make(map[string]*Word)
+	for form, variants := range clitics {
+		words[form] = newCliticWord(form, variants)
+	}
+	return words
+}()
+
+// the t of a-t-il, a particle without meaning
+var euphonicWord = func() *Word {
+	word := Word{}
+	word.form = "t"
+	word.Variants = append(word.Variants, WordVariant{Tag: PART, Language: FRENCH, Lemma: "t"})
+	return &word
+}()
+
+// hyphen separated part of a word, Pos includes the hyphen
+type hyphenPart struct {
+	Pos  []int
+	form string
+}
+
+// the parts -x after the hyphen at i, a part ending with an apostrophe
+// ends the list
+func hyphenParts(content string, i int) (parts []hyphenPart) {
+	for i < len(content) && content[i] == '-' {
+		end := i + 1
+		for end < len(content) {
+			r, l := utf8.DecodeRuneInString(content[end:])
+			if !unicode.IsLetter(r) {
+				break
+			}
+			end += l
+		}
+		if end == i+1 {
+			break
+		}
+		r, l := utf8.DecodeRuneInString(content[end:])
+		if end < len(content) && TokenizeIsApostrophe(r) {
+			parts = append(parts, hyphenPart{[]int{i, end + l}, TokenizeToLower(content[i+1:end]) + "'"})
+			break
+		}
+		parts = append(parts, hyphenPart{[]int{i, end}, TokenizeToLower(content[i+1 : end])})
+		i = end
+	}
+	return
+}
+
+// tokens of the word started at start and followed by the hyphen at i:
+// the longest hyphenated word of the lexicon as peut-être, else the
+// verb and its postposed clitics as dit -il or a -t -elle, nil when
+// the hyphen is a separator
+//
+// the clitic tokens are IsPostposed and their Pos includes the hyphen,
+// no DASH token is produced between the verb and its clitics
+func TokenizeClitics(content string, start int, i int, context *TokenizeContext) []Token {
+	parts := hyphenParts(content, i)
+	if len(parts) == 0 {
+		return nil
+	}
+	first, _ := utf8.DecodeRuneInString(content[start:])
+	isUpper := unicode.IsUpper(first)
+
+	for k := len(parts) - 1; k >= 0; k-- {
+		end := parts[k].Pos[1]
+		word, _ := TokenizeFindWord(content[start:end], context)
+		if word != nil {
+			return []Token{{Pos: []int{start, end}, Word: word, IsUpper: isUpper}}
+		}
+	}
+
+	verb, _ := TokenizeFindWord(content[start:i], context)
+	if verb == nil || !verb.Tagged(VERB) {
+		return nil
+	}
+	tokens := []Token{{Pos: []int{start, i}, Word: verb, IsUpper: isUpper}}
+	for k, part := range parts {
+		word := cliticWords[part.form]
+		if part.form == "t" && k+1 < len(parts) && euphonicSubjects[parts[k+1].form] {
+			word = euphonicWord
+		}
+		if word == nil {
+			return nil
+		}
+		tokens = append(tokens, Token{Pos: part.Pos, Word: word, IsPostposed: true})
+	}
+	return tokens
+}
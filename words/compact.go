@@ -0,0 +1,558 @@
+package words
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"unicode/utf8"
+)
+
+// Read-only minimal automaton (DAWG) built from a Dictionary.
+//
+// Words are numbered in rune order with a perfect hash: every node
+// stores the number of words below it and every edge the number of
+// words below its preceding siblings, so the path of a word gives its
+// index in the variant table. Identical suffixes are shared which
+// keeps the file small, and the file is used in place (memory mapped
+// when possible) instead of being rebuilt at load time.
+//
+// layout, little endian uint32 unless noted:
+//
+//	header   magic "BBDG", version, root, nodes, edges, words,
+//	         variants, lemmas, lemma blob size, max word len, max tokens
+//	nodes    [nodes] edge start, edge count | final bit, word count
+//	edges    [edges] rune, target node, words in preceding siblings
+//	words    [words+1] first variant of each word
+//	variants [variants] 8 feature bytes, lemma id
+//	lemmas   [lemmas+1] offset of each lemma in the blob
+//	blob     lemma bytes
+type CompactDictionary struct {
+	data  []byte
+	close func() error
+
+	root     uint32
+	nodes    int
+	edges    int
+	words    int
+	variants int
+	lemmas   int
+
+	nodeOffset    int
+	edgeOffset    int
+	wordOffset    int
+	variantOffset int
+	lemmaOffset   int
+	blobOffset    int
+
+	MaxWordLen int
+	MaxTokens  int
+}
+
+const (
+	CompactVersion = 1
+
+	compactHeaderSize  = 4 * 11
+	compactNodeSize    = 4 * 3
+	compactEdgeSize    = 4 * 3
+	compactVariantSize = 8 + 4
+	compactFinal       = 1 << 31
+)
+
+var CompactMagic = [4]byte{'B', 'B', 'D', 'G'}
+
+var ErrCompactCorrupt = errors.New("corrupt compact dictionary")
+
+// lookups common to Dictionary and CompactDictionary
+type Lexicon interface {
+	FindWord(letters string) (*Word, bool)
+	FindLonguestWord(letters string) *Word
+	Each(options WalkOptions, fn func(*Word) bool)
+	Walk(wordch chan *Word)
+	WalkOfSize(size int, wordch chan *Word)
+	WalkFromPath(word string, wordch chan *Word)
+	AutoComplete(word string, filter *WordVariant) []*Word
+	Limits() (maxWordLen int, maxTokens int)
+}
+
+var _ Lexicon = (*Dictionary)(nil)
+var _ Lexicon = (*CompactDictionary)(nil)
+
+// builder
+
+type compactBuilder struct {
+	registry map[string]uint32
+	nodes    []uint32
+	edges    []uint32
+	counts   []uint32
+}
+
+// registers the minimized automaton below letter and returns its node
+func (b *compactBuilder) add(letter *WordLetter) uint32 {
+	runes := letter.sortedRunes()
+
+	targets := make([]uint32, len(runes))
+	for i, r := range runes {
+		targets[i] = b.add(letter.Children[r])
+	}
+
+	var final uint32
+	if letter.Word != nil {
+		final = compactFinal
+	}
+
+	key := make([]byte, 0, 4+8*len(runes))
+	key = binary.LittleEndian.AppendUint32(key, final)
+	for i, r := range runes {
+		key = binary.LittleEndian.AppendUint32(key, uint32(r))
+		key = binary.LittleEndian.AppendUint32(key, targets[i])
+	}
+	if node, ok := b.registry[string(key)]; ok {
+		return node
+	}
+
+	node := uint32(len(b.counts))
+	count := uint32(0)
+	if final != 0 {
+		count = 1
+	}
+	start := uint32(len(b.edges) / 3)
+	var skip uint32
+	for i, r := range runes {
+		b.edges = append(b.edges, uint32(r), targets[i], skip)
+		skip += b.counts[targets[i]]
+	}
+	count += skip
+
+	b.nodes = append(b.nodes, start, uint32(len(runes))|final, count)
+	b.counts = append(b.counts, count)
+	b.registry[string(key)] = node
+	return node
+}
+
+// writes a new file renamed over path, a process mapping the previous
+// file keeps reading it until it is closed
+func (dict *Dictionary) WriteCompact(path string) error {
+	return writeFileAtomic(path, dict.EncodeCompact)
+}
+
+func (dict *Dictionary) EncodeCompact(w io.Writer) error {
+	b := compactBuilder{}
+	b.registry = make(map[string]uint32)
+	root := b.add(&dict.root)
+
+	// rune order is the order of the compact word index
+	var words []*Word
+	dict.Each(WalkOptions{}, func(w *Word) bool {
+		words = append(words, w)
+		return true
+	})
+
+	lemmaIds := map[string]uint32{"": 0}
+	lemmas := []string{""}
+	var wordTable []uint32
+	var variants []byte
+	for _, word := range words {
+		wordTable = append(wordTable, uint32(len(variants)/compactVariantSize))
+		for _, v := range word.Variants {
+			id, ok := lemmaIds[v.Lemma]
+			if !ok {
+				id = uint32(len(lemmas))
+				lemmaIds[v.Lemma] = id
+				lemmas = append(lemmas, v.Lemma)
+			}
+			variants = append(variants, v.Tag, v.Language, v.Flags, v.Subcat,
+				v.Person, v.Gender, v.Number, v.Tense)
+			variants = binary.LittleEndian.AppendUint32(variants, id)
+		}
+	}
+	wordTable = append(wordTable, uint32(len(variants)/compactVariantSize))
+
+	var blob []byte
+	var lemmaTable []uint32
+	for _, lemma := range lemmas {
+		lemmaTable = append(lemmaTable, uint32(len(blob)))
+		blob = append(blob, lemma...)
+	}
+	lemmaTable = append(lemmaTable, uint32(len(blob)))
+
+	header := []uint32{CompactVersion, root,
+		uint32(len(b.counts)), uint32(len(b.edges) / 3), uint32(len(words)),
+		uint32(len(variants) / compactVariantSize), uint32(len(lemmas)), uint32(len(blob)),
+		uint32(dict.MaxWordLen), uint32(dict.MaxTokens)}
+
+	sections := []interface{}{CompactMagic, header, b.nodes, b.edges, wordTable, variants, lemmaTable, blob}
+	for _, section := range sections {
+		err := binary.Write(w, binary.LittleEndian, section)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reader
+
+func ReadCompact(path string) (*CompactDictionary, error) {
+	data, close, err := compactMap(path)
+	if err != nil {
+		return nil, err
+	}
+	dict, err := NewCompactDictionary(data)
+	if err != nil {
+		close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	dict.close = close
+	return dict, nil
+}
+
+// uses data in place, data must not be modified afterwards
+func NewCompactDictionary(data []byte) (*CompactDictionary, error) {
+	if len(data) < compactHeaderSize || string(data[:4]) != string(CompactMagic[:]) {
+		return nil, fmt.Errorf("%w: bad magic", ErrCompactCorrupt)
+	}
+	header := make([]int, 10)
+	for i := range header {
+		header[i] = int(binary.LittleEndian.Uint32(data[4+4*i:]))
+	}
+	if header[0] != CompactVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrCompactCorrupt, header[0])
+	}
+
+	dict := CompactDictionary{}
+	dict.data = data
+	dict.root = uint32(header[1])
+	dict.nodes = header[2]
+	dict.edges = header[3]
+	dict.words = header[4]
+	dict.variants = header[5]
+	dict.lemmas = header[6]
+	dict.MaxWordLen = header[8]
+	dict.MaxTokens = header[9]
+
+	for _, count := range header[2:8] {
+		if count > len(data) {
+			return nil, fmt.Errorf("%w: count %d beyond size %d", ErrCompactCorrupt, count, len(data))
+		}
+	}
+
+	dict.nodeOffset = compactHeaderSize
+	dict.edgeOffset = dict.nodeOffset + dict.nodes*compactNodeSize
+	dict.wordOffset = dict.edgeOffset + dict.edges*compactEdgeSize
+	dict.variantOffset = dict.wordOffset + (dict.words+1)*4
+	dict.lemmaOffset = dict.variantOffset + dict.variants*compactVariantSize
+	dict.blobOffset = dict.lemmaOffset + (dict.lemmas+1)*4
+	size := dict.blobOffset + header[7]
+
+	if size != len(data) {
+		return nil, fmt.Errorf("%w: size %d, expected %d", ErrCompactCorrupt, len(data), size)
+	}
+	if dict.nodes == 0 || int(dict.root) >= dict.nodes {
+		return nil, fmt.Errorf("%w: bad root %d", ErrCompactCorrupt, dict.root)
+	}
+	err := dict.check(header[7])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrCompactCorrupt, err)
+	}
+	return &dict, nil
+}
+
+// checks every offset of the tables so that lookups stay in data: edges
+// go to nodes built before, word counts and skips are those of the
+// targets so indexes stay below the word count, variant and lemma
+// tables are ordered and end with their section
+func (dict *CompactDictionary) check(blobSize int) error {
+	counts := make([]uint64, dict.nodes)
+	for node := 0; node < dict.nodes; node++ {
+		start, count := dict.nodeEdges(uint32(node))
+		if start+count > dict.edges {
+			return fmt.Errorf("node %d edges %d+%d beyond %d", node, start, count, dict.edges)
+		}
+		var words uint64
+		if dict.final(uint32(node)) {
+			words = 1
+		}
+		var skip uint64
+		for e := start; e < start+count; e++ {
+			_, target, edgeSkip := dict.edge(e)
+			if int(target) >= node {
+				return fmt.Errorf("edge %d of node %d to node %d", e, node, target)
+			}
+			if uint64(edgeSkip) != skip {
+				return fmt.Errorf("edge %d skips %d words, expected %d", e, edgeSkip, skip)
+			}
+			skip += counts[target]
+		}
+		words += skip
+		if uint64(dict.uint32At(dict.nodeOffset+node*compactNodeSize+8)) != words {
+			return fmt.Errorf("node %d has %d words", node, words)
+		}
+		counts[node] = words
+	}
+	if counts[dict.root] != uint64(dict.words) {
+		return fmt.Errorf("root has %d words, header says %d", counts[dict.root], dict.words)
+	}
+
+	if err := dict.checkTable(dict.wordOffset, dict.words, dict.variants); err != nil {
+		return fmt.Errorf("word table: %s", err)
+	}
+	for i := 0; i < dict.variants; i++ {
+		if id := dict.uint32At(dict.variantOffset + i*compactVariantSize + 8); int(id) >= dict.lemmas {
+			return fmt.Errorf("variant %d has lemma %d of %d", i, id, dict.lemmas)
+		}
+	}
+	if err := dict.checkTable(dict.lemmaOffset, dict.lemmas, blobSize); err != nil {
+		return fmt.Errorf("lemma table: %s", err)
+	}
+	return nil
+}
+
+// the n+1 entries at offset go from 0 to last in order
+func (dict *CompactDictionary) checkTable(offset int, n int, last int) error {
+	previous := uint32(0)
+	for i := 0; i <= n; i++ {
+		value := dict.uint32At(offset + i*4)
+		if value < previous || i == 0 && value != 0 {
+			return fmt.Errorf("entry %d is %d after %d", i, value, previous)
+		}
+		previous = value
+	}
+	if int(previous) != last {
+		return fmt.Errorf("ends at %d, expected %d", previous, last)
+	}
+	return nil
+}
+
+func (dict *CompactDictionary) Close() error {
+	if dict.close == nil {
+		return nil
+	}
+	err := dict.close()
+	dict.close = nil
+	dict.data = nil
+	return err
+}
+
+func (dict *CompactDictionary) NumWords() int {
+	return dict.words
+}
+
+func (dict *CompactDictionary) NumNodes() int {
+	return dict.nodes
+}
+
+func (dict *CompactDictionary) Limits() (int, int) {
+	return dict.MaxWordLen, dict.MaxTokens
+}
+
+func (dict *CompactDictionary) uint32At(offset int) uint32 {
+	return binary.LittleEndian.Uint32(dict.data[offset:])
+}
+
+func (dict *CompactDictionary) final(node uint32) bool {
+	return dict.uint32At(dict.nodeOffset+int(node)*compactNodeSize+4)&compactFinal != 0
+}
+
+func (dict *CompactDictionary) nodeEdges(node uint32) (start int, count int) {
+	offset := dict.nodeOffset + int(node)*compactNodeSize
+	start = int(dict.uint32At(offset))
+	count = int(dict.uint32At(offset+4) &^ compactFinal)
+	return
+}
+
+func (dict *CompactDictionary) edge(e int) (r rune, target uint32, skip uint32) {
+	offset := dict.edgeOffset + e*compactEdgeSize
+	r = rune(dict.uint32At(offset))
+	target = dict.uint32At(offset + 4)
+	skip = dict.uint32At(offset + 8)
+	return
+}
+
+// follows the edge labelled r, index is the word index of the first
+// word reachable from node and is updated for the target node
+func (dict *CompactDictionary) step(node uint32, index uint32, r rune) (uint32, uint32, bool) {
+	start, count := dict.nodeEdges(node)
+	e := sort.Search(count, func(i int) bool {
+		er, _, _ := dict.edge(start + i)
+		return er >= r
+	})
+	if e == count {
+		return 0, 0, false
+	}
+	er, target, skip := dict.edge(start + e)
+	if er != r {
+		return 0, 0, false
+	}
+	if dict.final(node) {
+		index++
+	}
+	return target, index + skip, true
+}
+
+func (dict *CompactDictionary) word(index uint32, form string) *Word {
+	word := &Word{}
+	word.form = form
+
+	first := int(dict.uint32At(dict.wordOffset + int(index)*4))
+	last := int(dict.uint32At(dict.wordOffset + int(index+1)*4))
+	for i := first; i < last; i++ {
+		offset := dict.variantOffset + i*compactVariantSize
+		b := dict.data[offset : offset+8]
+		v := WordVariant{Tag: b[0], Language: b[1], Flags: b[2], Subcat: b[3],
+			Person: b[4], Gender: b[5], Number: b[6], Tense: b[7]}
+		v.Lemma = dict.lemma(dict.uint32At(offset + 8))
+		word.Variants = append(word.Variants, v)
+	}
+	return word
+}
+
+func (dict *CompactDictionary) lemma(id uint32) string {
+	if id == 0 {
+		return ""
+	}
+	start := dict.uint32At(dict.lemmaOffset + int(id)*4)
+	end := dict.uint32At(dict.lemmaOffset + int(id+1)*4)
+	return string(dict.data[dict.blobOffset+int(start) : dict.blobOffset+int(end)])
+}
+
+// position in the automaton returned by FindPath
+type CompactPath struct {
+	dict  *CompactDictionary
+	node  uint32
+	index uint32
+	form  string
+}
+
+func (path *CompactPath) Word() *Word {
+	if !path.dict.final(path.node) {
+		return nil
+	}
+	return path.dict.word(path.index, path.form)
+}
+
+func (path *CompactPath) Walk(wordch chan *Word) {
+	path.dict.walk(path.node, path.index, []byte(path.form), -1, -1, func(w *Word) bool {
+		wordch <- w
+		return true
+	})
+}
+
+func (dict *CompactDictionary) FindPath(letters string) *CompactPath {
+	node, index := dict.root, uint32(0)
+	var ok bool
+	for _, r := range letters {
+		node, index, ok = dict.step(node, index, r)
+		if !ok {
+			return nil
+		}
+	}
+	if len(letters) == 0 {
+		return nil
+	}
+	return &CompactPath{dict, node, index, letters}
+}
+
+func (dict *CompactDictionary) FindWord(letters string) (*Word, bool) {
+	path := dict.FindPath(letters)
+	if path == nil {
+		return nil, len(letters) == 0
+	}
+	return path.Word(), true
+}
+
+func (dict *CompactDictionary) FindLonguestWord(letters string) *Word {
+	var word *Word
+	node, index := dict.root, uint32(0)
+	var ok bool
+	for i, r := range letters {
+		node, index, ok = dict.step(node, index, r)
+		if !ok {
+			break
+		}
+		if dict.final(node) {
+			word = dict.word(index, letters[:i+utf8.RuneLen(r)])
+		}
+	}
+	return word
+}
+
+// size and depth are the runes left as in WalkOptions.bounds
+func (dict *CompactDictionary) walk(node uint32, index uint32, prefix []byte, size int, depth int, fn func(*Word) bool) bool {
+	final := dict.final(node)
+	if final && size <= 0 && !fn(dict.word(index, string(prefix))) {
+		return false
+	}
+	if size == 0 || depth == 0 {
+		return true
+	}
+	if final {
+		index++
+	}
+	start, count := dict.nodeEdges(node)
+	for e := start; e < start+count; e++ {
+		r, target, skip := dict.edge(e)
+		if !dict.walk(target, index+skip, utf8.AppendRune(prefix, r), size-1, depth-1, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// calls fn on the words within options in rune order until fn
+// returns false
+func (dict *CompactDictionary) Each(options WalkOptions, fn func(*Word) bool) {
+	size, depth, ok := options.bounds()
+	if !ok {
+		return
+	}
+	node, index, prefix := dict.root, uint32(0), []byte(nil)
+	if len(options.Prefix) > 0 {
+		path := dict.FindPath(options.Prefix)
+		if path == nil {
+			return
+		}
+		node, index, prefix = path.node, path.index, []byte(path.form)
+	}
+	dict.walk(node, index, prefix, size, depth, fn)
+}
+
+func (dict *CompactDictionary) Walk(wordch chan *Word) {
+	dict.Each(WalkOptions{}, func(w *Word) bool {
+		wordch <- w
+		return true
+	})
+	close(wordch)
+}
+
+func (dict *CompactDictionary) WalkOfSize(size int, wordch chan *Word) {
+	dict.walk(dict.root, 0, nil, size, -1, func(w *Word) bool {
+		wordch <- w
+		return true
+	})
+	close(wordch)
+}
+
+func (dict *CompactDictionary) WalkFromPath(word string, wordch chan *Word) {
+	path := dict.FindPath(word)
+	if path != nil {
+		path.Walk(wordch)
+	}
+	close(wordch)
+}
+
+func (dict *CompactDictionary) AutoComplete(word string, filter *WordVariant) []*Word {
+	var words []*Word
+
+	if len(word) == 0 {
+		return nil
+	}
+	dict.Each(WalkOptions{Prefix: word}, func(w *Word) bool {
+		if filter == nil || filter.Filter(w) {
+			words = append(words, w)
+		}
+		return true
+	})
+	return words
+}
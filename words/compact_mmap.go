@@ -0,0 +1,33 @@
+//go:build unix
+
+package words
+
+import (
+	"os"
+	"syscall"
+)
+
+func compactMap(path string) (data []byte, close func() error, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	if info.Size() == 0 {
+		return []byte{}, func() error { return nil }, nil
+	}
+
+	data, err = syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return
+	}
+	close = func() error {
+		return syscall.Munmap(data)
+	}
+	return
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package words
+
+import (
+	"io/ioutil"
+)
+
+func compactMap(path string) (data []byte, close func() error, err error) {
+	data, err = ioutil.ReadFile(path)
+	close = func() error { return nil }
+	return
+}
@@ -0,0 +1,260 @@
+package words
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func GetCompactTestDictionary() *Dictionary {
+	dict := GetLemmaDictionary()
+	for _, form := range []string{"lance", "lancer", "lances", "test", "à", "à affirmer", "été"} {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: VERB, Language: FRENCH, Lemma: form})
+		dict.AddWord(form, &word)
+	}
+	return dict
+}
+
+func GetCompactDictionary(t *testing.T, dict *Dictionary) *CompactDictionary {
+	var buf bytes.Buffer
+	err := dict.EncodeCompact(&buf)
+	if err != nil {
+		t.Fatalf("cannot encode compact %s", err)
+	}
+	compact, err := NewCompactDictionary(buf.Bytes())
+	if err != nil {
+		t.Fatalf("cannot decode compact %s", err)
+	}
+	return compact
+}
+
+func CollectWords(walk func(chan *Word)) (words []string) {
+	wordch := make(chan *Word)
+	go walk(wordch)
+	for word := range wordch {
+		words = append(words, word.String())
+	}
+	return
+}
+
+func TestCompactFindWord(t *testing.T) {
+	dict := GetCompactTestDictionary()
+	compact := GetCompactDictionary(t, dict)
+
+	for _, form := range []string{"chien", "chiennes", "lancer", "à", "à affirmer", "été"} {
+		word, found := compact.FindWord(form)
+		if word == nil || !found {
+			t.Errorf("%s not found", form)
+			continue
+		}
+		expected, _ := dict.FindWord(form)
+		if word.String() != form || len(word.Variants) != len(expected.Variants) {
+			t.Errorf("%s mismatch %s", form, word.Description())
+		}
+		if !word.Variants[0].Equals(&expected.Variants[0]) {
+			t.Errorf("%s variant mismatch", form)
+		}
+	}
+
+	word, found := compact.FindWord("chienn")
+	if word != nil || !found {
+		t.Errorf("chienn path mismatch")
+	}
+	word, found = compact.FindWord("chat")
+	if word != nil || found {
+		t.Errorf("chat found")
+	}
+
+	word = compact.FindLonguestWord("à affirmer la vie")
+	if word == nil || word.String() != "à affirmer" {
+		t.Errorf("à affirmer not found")
+	}
+}
+
+func TestCompactWalk(t *testing.T) {
+	dict := GetCompactTestDictionary()
+	compact := GetCompactDictionary(t, dict)
+
+	expected := CollectWords(dict.Walk)
+	sort.Strings(expected)
+	words := CollectWords(compact.Walk)
+	if !sort.StringsAreSorted(words) {
+		t.Errorf("compact walk not sorted %v", words)
+	}
+	if len(words) != len(expected) || len(words) != compact.NumWords() {
+		t.Fatalf("compact walk mismatch %v %v", words, expected)
+	}
+	for i := range words {
+		if words[i] != expected[i] {
+			t.Errorf("compact walk mismatch %s %s", words[i], expected[i])
+		}
+	}
+
+	for _, word := range CollectWords(func(wordch chan *Word) { compact.WalkOfSize(5, wordch) }) {
+		if word != "chien" && word != "lance" {
+			t.Errorf("bad word of size 5 %s", word)
+		}
+	}
+
+	completed := compact.AutoComplete("lance", nil)
+	if len(completed) != 3 {
+		t.Errorf("lance completions %d", len(completed))
+	}
+}
+
+func TestCompactMinimal(t *testing.T) {
+	dict := Dictionary{}
+	for _, form := range []string{"chanter", "danser", "manger", "chante", "danse", "mange"} {
+		dict.AddWord(form, &Word{})
+	}
+	compact := GetCompactDictionary(t, &dict)
+
+	// suffixes "er" and "e" are shared by the three stems
+	if compact.NumNodes() >= 20 {
+		t.Errorf("automaton not minimized %d nodes", compact.NumNodes())
+	}
+	word, _ := compact.FindWord("danser")
+	if word == nil {
+		t.Errorf("danser not found")
+	}
+}
+
+func TestCompactFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.dawg")
+	dict := GetCompactTestDictionary()
+	err := dict.WriteCompact(path)
+	if err != nil {
+		t.Fatalf("cannot write compact %s", err)
+	}
+
+	compact, err := ReadCompact(path)
+	if err != nil {
+		t.Fatalf("cannot read compact %s", err)
+	}
+	defer compact.Close()
+
+	lemmas := []string{}
+	word, _ := compact.FindWord("chiennes")
+	if word != nil {
+		lemmas = append(lemmas, word.Variants[0].Lemma)
+	}
+	if len(lemmas) != 1 || lemmas[0] != "chien" {
+		t.Errorf("chiennes lemma mismatch %v", lemmas)
+	}
+
+	_, err = NewCompactDictionary([]byte("BBDG"))
+	if err == nil {
+		t.Errorf("truncated compact dictionary accepted")
+	}
+
+	// rebuilding does not touch the mapped file
+	rebuilt := GetLemmaDictionary()
+	word = &Word{}
+	word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Lemma: "chat"})
+	rebuilt.AddWord("chat", word)
+	err = rebuilt.WriteCompact(path)
+	if err != nil {
+		t.Fatalf("cannot rewrite compact %s", err)
+	}
+	if word, _ := compact.FindWord("lancer"); word == nil {
+		t.Errorf("mapped compact dictionary changed by a rebuild")
+	}
+	reread, err := ReadCompact(path)
+	if err != nil {
+		t.Fatalf("cannot read rebuilt compact %s", err)
+	}
+	defer reread.Close()
+	if word, _ := reread.FindWord("chat"); word == nil {
+		t.Errorf("rebuilt compact dictionary not written")
+	}
+}
+
+func TestCompactTokenize(t *testing.T) {
+	dict := GetCompactTestDictionary()
+	dict.AddBuiltin()
+	dict.AddWordWithTag(" ", SPACE)
+	dict.AddWord("pas le choix", &Word{})
+
+	context := NewTestContext(GetCompactDictionary(t, dict))
+
+	text := "chiennes à affirmer"
+	tokens := Tokenize(text, context, true)
+	if len(tokens) != 3 {
+		t.Fatalf("bad token count %d", len(tokens))
+	}
+	if tokens[0].Word == nil || tokens[2].Content(text) != "à affirmer" || tokens[2].Word == nil {
+		t.Errorf("compact tokens mismatch")
+		TokenizePrintTokens(text, tokens)
+	}
+}
+
+func TestCompactEach(t *testing.T) {
+	dict := GetCompactTestDictionary()
+	compact := GetCompactDictionary(t, dict)
+
+	options := []WalkOptions{
+		{},
+		{Prefix: "chien"},
+		{Prefix: "chien", Depth: 1},
+		{Prefix: "lance", Size: 6},
+		{Size: 5},
+		{Prefix: "chat"},
+	}
+	for _, o := range options {
+		expected := strings.Join(CollectEach(dict, o), " ")
+		words := strings.Join(CollectEach(compact, o), " ")
+		if words != expected {
+			t.Errorf("%+v gives '%s' not '%s'", o, words, expected)
+		}
+	}
+
+	count := 0
+	compact.Each(WalkOptions{}, func(w *Word) bool {
+		count++
+		return w.String() != "chiennes"
+	})
+	if count != 3 {
+		t.Errorf("compact walk not stopped %d", count)
+	}
+}
+
+func TestCompactCorrupt(t *testing.T) {
+	dict := GetCompactTestDictionary()
+	var buf bytes.Buffer
+	err := dict.EncodeCompact(&buf)
+	if err != nil {
+		t.Fatalf("cannot encode compact %s", err)
+	}
+	data := buf.Bytes()
+
+	// any byte changed after the header is rejected or harmless
+	for i := compactHeaderSize; i < len(data); i++ {
+		for _, flip := range []byte{0x01, 0x80, 0xff} {
+			corrupt := append([]byte{}, data...)
+			corrupt[i] ^= flip
+			compact, err := NewCompactDictionary(corrupt)
+			if err != nil {
+				if !errors.Is(err, ErrCompactCorrupt) {
+					t.Errorf("byte %d: bad error %s", i, err)
+				}
+				continue
+			}
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("byte %d ^ %x accepted and panics: %v", i, flip, r)
+					}
+				}()
+				compact.Each(WalkOptions{}, func(w *Word) bool { return true })
+				for _, form := range []string{"chien", "chiennes", "lancer", "à affirmer", "été"} {
+					compact.FindWord(form)
+					compact.FindLonguestWord(form)
+				}
+			}()
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package words
 
 import (
+	"bufio"
 	"encoding/xml"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"os"
 	"strings"
 )
 
@@ -30,31 +34,98 @@ type Entry struct {
 type WordList struct {
 	XMLName xml.Name `xml:"dico"`
 	Entries []Entry  `xml:"entry"`
+
+	// called every ProgressEntries entries and when done with the
+	// bytes read so far and the file size
+	Progress        func(read int64, total int64, entries int) `xml:"-"`
+	ProgressEntries int                                        `xml:"-"`
+
+	// unknown attribute policy and what was read
+	Policy  byte        `xml:"-"`
+	Summary ReadSummary `xml:"-"`
 }
 
-func (words *WordList) Read(path string, lang byte, addWord func(form string, word *Word)(error)) (err error) {
-	bytes, err := ioutil.ReadFile(path)
+// streams entries to addWord without keeping them in Entries
+func (words *WordList) Read(path string, lang byte, addWord func(form string, word *Word) error) (err error) {
+	f, err := os.Open(path)
 	if err != nil {
 		return
 	}
-	err = xml.Unmarshal(bytes, words)
-	if err != nil {
-		return err
+	defer f.Close()
+
+	var total int64
+	info, err := f.Stat()
+	if err == nil {
+		total = info.Size()
 	}
 
-	for _, entry := range words.Entries {
-		for _, inflection := range entry.Inflections {
-			word := Word{}
-			v := inflection.GetVariant(&entry, lang)
-			word.Variants = append(word.Variants, v)
+	every := words.ProgressEntries
+	if every <= 0 {
+		every = 10000
+	}
 
-			form := strings.Replace(inflection.Form, "\\-", "-", -1)
-			err = addWord(form, &word)
-			if err != nil {
-				return err
-			}
+	decoder := xml.NewDecoder(bufio.NewReader(f))
+	entries := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			line, _ := decoder.InputPos()
+			return fmt.Errorf("%s:%d: offset %d: %w", path, line, decoder.InputOffset(), err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "entry" {
+			continue
+		}
+
+		line, _ := decoder.InputPos()
+		offset := decoder.InputOffset()
+		entry := Entry{}
+		err = decoder.DecodeElement(&entry, &start)
+		if err != nil {
+			return fmt.Errorf("%s:%d: entry at offset %d: %w", path, line, offset, err)
+		}
+
+		err = words.addEntry(&entry, lang, addWord)
+		if err != nil {
+			return fmt.Errorf("%s:%d: entry '%s' at offset %d: %w", path, line, entry.Lemma, offset, err)
+		}
+
+		entries++
+		words.Summary.Entries++
+		if words.Progress != nil && entries%every == 0 {
+			words.Progress(decoder.InputOffset(), total, entries)
 		}
 	}
+	if words.Progress != nil {
+		words.Progress(decoder.InputOffset(), total, entries)
+	}
+	return nil
+}
+
+func (words *WordList) addEntry(entry *Entry, lang byte, addWord func(form string, word *Word) error) (err error) {
+	for _, inflection := range entry.Inflections {
+		word := Word{}
+		v, verr := inflection.GetVariant(entry, lang)
+		keep, err := words.Summary.apply(words.Policy, verr)
+		if err != nil {
+			return err
+		}
+		if !keep {
+			continue
+		}
+		word.Variants = append(word.Variants, v)
+
+		form := strings.Replace(inflection.Form, "\\-", "-", -1)
+		err = addWord(form, &word)
+		if err != nil {
+			return err
+		}
+		words.Summary.Forms++
+	}
 	return
 }
 
@@ -67,118 +138,80 @@ func (words *WordList) Write(path string) (err error) {
 	return
 }
 
-func (inf *Inflected) GetVariant(entry *Entry, lang byte) WordVariant {
-	variation := WordVariant{}
+// pos names of the dela xml files, "" is no pos
+var delaPos = map[string]byte{
+	"noun":             NOUN,
+	"prep":             PREP,
+	"adverb":           ADVERB,
+	"verb":             VERB,
+	"adj":              ADJ,
+	"nominaldet":       NOMINALDET,
+	"prefix":           PREFIX,
+	"GNP":              GNP,
+	"GNPX":             GNPX,
+	"conjs":            CONJS,
+	"X":                X,
+	"PCDN3":            PCDN3,
+	"intj":             INTJ,
+	"GN":               GN,
+	"conjc":            CONJC,
+	"det":              DET,
+	"pronoun":          PRONOUN,
+	"prepadj":          PREPADJ,
+	"prepdet":          PREPDET,
+	"preppro":          PREPPRO,
+	"PRON":             PRON,
+	"XI":               XI,
+	"PART":             PART,
+	"PRED":             PRED,
+	"conj":             CONJ,
+	" Clark.N":         CLARKN,
+	"VA":               VA,
+	"NA":               NA,
+	" Ghery.N":         GHERYN,
+	" G\\. Wells.N":    GWELLSN,
+	" Mayer.N":         MAYERN,
+	"NES":              NES,
+	" Maugham.N":       MAUGHAMN,
+	"ADVA":             ADVA,
+	" C\\. Fields.N":   CFIELDSN,
+	" Hart.N":          HARTN,
+	"abbr":             ABBR,
+	"space":            SPACE,
+	"comma":            COMMA,
+	"dot":              DOT,
+	"colon":            COLON,
+	"questionmark":     QUESTIONMARK,
+	"exclamationmark":  EXCLAMATIONMARK,
+	"beginparenthesis": BEGINPARENTHESIS,
+	"endparenthesis":   ENDPARENTHESIS,
+	"apos":             APOS,
+	"quotationmark":    QUOTATIONMARK,
+	"beginquotation":   BEGINQUOTATION,
+	"endquotation":     ENDQUOTATION,
+	"dash":             DASH,
+	"tab":              TAB,
+	"":                 0,
+}
+
+// on error the variant is still filled with unknown pos mapped to X and
+// unknown features dropped, err is the first *VariantError met
+func (inf *Inflected) GetVariant(entry *Entry, lang byte) (variation WordVariant, err error) {
+	unknown := func(attribute string, value string) {
+		if err == nil {
+			err = &VariantError{entry.Lemma, inf.Form, attribute, value}
+		}
+	}
+
 	variation.Gender = NOGENDER
 	variation.Language = lang
-	switch entry.Tag.Name {
-	case "noun":
-		variation.Tag = NOUN
-	case "prep":
-		variation.Tag = PREP
-	case "adverb":
-		variation.Tag = ADVERB
-	case "verb":
-		variation.Tag = VERB
-	case "adj":
-		variation.Tag = ADJ
-	case "nominaldet":
-		variation.Tag = NOMINALDET
-	case "prefix":
-		variation.Tag = PREFIX
-	case "GNP":
-		variation.Tag = GNP
-	case "GNPX":
-		variation.Tag = GNPX
-	case "conjs":
-		variation.Tag = CONJS
-	case "X":
-		variation.Tag = X
-	case "PCDN3":
-		variation.Tag = PCDN3
-	case "intj":
-		variation.Tag = INTJ
-	case "GN":
-		variation.Tag = GN
-	case "conjc":
-		variation.Tag = CONJC
-	case "det":
-		variation.Tag = DET
-	case "pronoun":
-		variation.Tag = PRONOUN
-	case "prepadj":
-		variation.Tag = PREPADJ
-	case "prepdet":
-		variation.Tag = PREPDET
-	case "preppro":
-		variation.Tag = PREPPRO
-	case "PRON":
-		variation.Tag = PRON
-	case "XI":
-		variation.Tag = XI
-	case "PART":
-		variation.Tag = PART
-	case "PRED":
-		variation.Tag = PRED
-	case "conj":
-		variation.Tag = CONJ
-	case " Clark.N":
-		variation.Tag = CLARKN
-	case "VA":
-		variation.Tag = VA
-	case "NA":
-		variation.Tag = NA
-	case " Ghery.N":
-		variation.Tag = GHERYN
-	case " G\\. Wells.N":
-		variation.Tag = GWELLSN
-	case " Mayer.N":
-		variation.Tag = MAYERN
-	case "NES":
-		variation.Tag = NES
-	case " Maugham.N":
-		variation.Tag = MAUGHAMN
-	case "ADVA":
-		variation.Tag = ADVA
-	case " C\\. Fields.N":
-		variation.Tag = CFIELDSN
-	case " Hart.N":
-		variation.Tag = HARTN
-	case "abbr":
-		variation.Tag = ABBR
-	case "space":
-		variation.Tag = SPACE
-	case "comma":
-		variation.Tag = COMMA
-	case "dot":
-		variation.Tag = DOT
-	case "colon":
-		variation.Tag = COLON
-	case "questionmark":
-		variation.Tag = QUESTIONMARK
-	case "exclamationmark":
-		variation.Tag = EXCLAMATIONMARK
-	case "beginparenthesis":
-		variation.Tag = BEGINPARENTHESIS
-	case "endparenthesis":
-		variation.Tag = ENDPARENTHESIS
-	case "apos":
-		variation.Tag = APOS
-	case "quotationmark":
-		variation.Tag = QUOTATIONMARK
-	case "beginquotation":
-		variation.Tag = BEGINQUOTATION
-	case "endquotation":
-		variation.Tag = ENDQUOTATION
-	case "dash":
-		variation.Tag = DASH
-	case "tab":
-		variation.Tag = TAB
-	case "":
-		variation.Tag = 0
-	default:
-		panic("'" + entry.Tag.Name + "'")
+	variation.Lemma = strings.Replace(entry.Lemma, "\\-", "-", -1)
+	tag, ok := delaPos[entry.Tag.Name]
+	if !ok {
+		tag = X
+		unknown("pos", entry.Tag.Name)
 	}
+	variation.Tag = tag
 	for _, feat := range entry.Feats {
 		switch feat.Name {
 		case "proper":
@@ -186,7 +219,7 @@ func (inf *Inflected) GetVariant(entry *Entry, lang byte) WordVariant {
 			case "true":
 				variation.Flags |= PROPER
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "subcat":
 			switch feat.Value {
@@ -207,45 +240,45 @@ func (inf *Inflected) GetVariant(entry *Entry, lang byte) WordVariant {
 			case "demonstrative":
 				variation.Subcat = DEMONSTRATIVE
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "compound":
 			switch feat.Value {
 			case "comp":
 				variation.Flags |= COMPOUND
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "coll":
 			switch feat.Value {
 			case "true":
 				variation.Flags |= COLL
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "postpos":
 			switch feat.Value {
 			case "true":
 				variation.Flags |= POSTPOS
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "collective":
 			switch feat.Value {
 			case "true":
 				variation.Flags |= COLLECTIVE
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "procat":
 			switch feat.Value {
 			case "demonstrative":
 				variation.Flags |= PROCATDEMONSTRATIVE
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		default:
-			panic(feat.Name)
+			unknown(feat.Name, feat.Value)
 		}
 	}
 	for _, feat := range inf.Feats {
@@ -257,7 +290,7 @@ func (inf *Inflected) GetVariant(entry *Entry, lang byte) WordVariant {
 			case "feminine":
 				variation.Gender = FEMALE
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "number":
 			switch feat.Value {
@@ -266,7 +299,7 @@ func (inf *Inflected) GetVariant(entry *Entry, lang byte) WordVariant {
 			case "plural":
 				variation.Number = PLURAL
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "person":
 			switch feat.Value {
@@ -277,7 +310,7 @@ func (inf *Inflected) GetVariant(entry *Entry, lang byte) WordVariant {
 			case "3":
 				variation.Person = 3
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		case "tense":
 			switch feat.Value {
@@ -296,11 +329,11 @@ func (inf *Inflected) GetVariant(entry *Entry, lang byte) WordVariant {
 			case "inf":
 				variation.Tense = INF
 			default:
-				panic(feat.Value)
+				unknown(feat.Name, feat.Value)
 			}
 		default:
-			panic(feat.Name)
+			unknown(feat.Name, feat.Value)
 		}
 	}
-	return variation
+	return
 }
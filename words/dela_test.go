@@ -0,0 +1,125 @@
+package words
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testDela = `<?xml version="1.0" encoding="UTF-8"?>
+<dico>
+<entry><lemma>chien</lemma><pos name="noun"/>
+<inflected><form>chien</form><feat name="gender" value="masculine"/><feat name="number" value="singular"/></inflected>
+<inflected><form>chiennes</form><feat name="gender" value="feminine"/><feat name="number" value="plural"/></inflected>
+</entry>
+<entry><lemma>porte-avion</lemma><pos name="noun"/>
+<inflected><form>porte\-avions</form><feat name="number" value="plural"/></inflected>
+</entry>
+</dico>
+`
+
+func TestWordListRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.dic.xml")
+	err := ioutil.WriteFile(path, []byte(testDela), 0666)
+	if err != nil {
+		t.Fatalf("cannot write dela %s", err)
+	}
+
+	progress := 0
+	dict := Dictionary{}
+	dict.Progress = func(path string, read int64, total int64, entries int) {
+		progress = entries
+		if read > total {
+			t.Errorf("read %d past total %d", read, total)
+		}
+	}
+	_, err = dict.ReadLanguage(path, FRENCH, FAILUNKNOWN)
+	if err != nil {
+		t.Fatalf("cannot read dela %s", err)
+	}
+	if progress != 2 {
+		t.Errorf("progress reported %d entries", progress)
+	}
+
+	word, _ := dict.FindWord("chiennes")
+	if word == nil || word.Variants[0].Gender != FEMALE || word.Variants[0].Lemma != "chien" {
+		t.Errorf("chiennes not read")
+	}
+	word, _ = dict.FindWord("porte-avions")
+	if word == nil || word.Variants[0].Lemma != "porte-avion" {
+		t.Errorf("porte-avions not unescaped")
+	}
+}
+
+func TestWordListReadError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.dic.xml")
+	bad := strings.Replace(testDela, "</entry>\n<entry>", "</entry>\n<entry><lemma>x</lemma></bad>\n<entry>", 1)
+	err := ioutil.WriteFile(path, []byte(bad), 0666)
+	if err != nil {
+		t.Fatalf("cannot write dela %s", err)
+	}
+
+	dict := Dictionary{}
+	_, err = dict.ReadLanguage(path, FRENCH, FAILUNKNOWN)
+	if err == nil {
+		t.Fatalf("bad dela accepted")
+	}
+	if !strings.HasPrefix(err.Error(), path+":7: entry at offset") {
+		t.Errorf("bad error location %s", err)
+	}
+}
+
+func TestReadPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.dic.xml")
+	odd := strings.Replace(testDela, `<pos name="noun"/>`, `<pos name="oddpos"/>`, 1)
+	odd = strings.Replace(odd, `value="plural"/></inflected>
+</entry>
+</dico>`, `value="plural"/><feat name="mood" value="odd"/></inflected>
+</entry>
+</dico>`, 1)
+	err := ioutil.WriteFile(path, []byte(odd), 0666)
+	if err != nil {
+		t.Fatalf("cannot write dela %s", err)
+	}
+
+	dict := Dictionary{}
+	_, err = dict.ReadLanguage(path, FRENCH, FAILUNKNOWN)
+	var variantErr *VariantError
+	if !errors.As(err, &variantErr) {
+		t.Fatalf("unknown pos not reported %v", err)
+	}
+	if variantErr.Lemma != "chien" || variantErr.Form != "chien" || variantErr.Attribute != "pos" || variantErr.Value != "oddpos" {
+		t.Errorf("bad variant error %v", variantErr)
+	}
+
+	dict = Dictionary{}
+	summary, err := dict.ReadLanguage(path, FRENCH, SKIPUNKNOWN)
+	if err != nil {
+		t.Fatalf("skip policy failed %s", err)
+	}
+	if summary.Entries != 2 || summary.Forms != 0 || summary.Skipped != 3 {
+		t.Errorf("bad skip summary %s", summary.String())
+	}
+	if summary.Unknown["pos=oddpos"] != 2 || summary.Unknown["mood=odd"] != 1 {
+		t.Errorf("bad unknown counts %v", summary.Unknown)
+	}
+
+	dict = Dictionary{}
+	summary, err = dict.ReadLanguage(path, FRENCH, MAPUNKNOWN)
+	if err != nil {
+		t.Fatalf("map policy failed %s", err)
+	}
+	if summary.Forms != 3 || summary.Mapped != 3 {
+		t.Errorf("bad map summary %s", summary.String())
+	}
+	word, _ := dict.FindWord("chien")
+	if word == nil || !word.Tagged(X) {
+		t.Errorf("unknown pos not mapped to X")
+	}
+	word, _ = dict.FindWord("porte-avions")
+	if word == nil || !word.Tagged(NOUN) || word.Variants[0].Number != PLURAL {
+		t.Errorf("unknown feature not dropped")
+	}
+}
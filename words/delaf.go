@@ -0,0 +1,329 @@
+package words
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// dictionary source formats
+const (
+	FormatDelaXML = "xml"   // converted dela xml
+	FormatDelaf   = "delaf" // unitex DELAF/DELAS text
+)
+
+// one line of a DELAF file: form,lemma.POS+sem1+sem2:inf1:inf2
+// DELAS lines have no lemma and an inflection class: form,N32+Hum
+type DelafEntry struct {
+	Form        string
+	Lemma       string
+	Pos         string
+	Semantic    []string
+	Inflections []string
+}
+
+var delafPos = map[string]byte{
+	"N":     NOUN,
+	"A":     ADJ,
+	"V":     VERB,
+	"ADV":   ADVERB,
+	"PREP":  PREP,
+	"DET":   DET,
+	"PRO":   PRONOUN,
+	"CONJC": CONJC,
+	"CONJS": CONJS,
+	"INTJ":  INTJ,
+	"PFX":   PREFIX,
+	"ABR":   ABBR,
+	"ABBR":  ABBR,
+	"X":     X,
+	"XI":    XI,
+	"PART":  PART,
+	"PRED":  PRED,
+	"GN":    GN,
+	"GNP":   GNP,
+	"GNPX":  GNPX,
+	"NES":   NES,
+	"NA":    NA,
+	"VA":    VA,
+	"ADVA":  ADVA,
+	"PCDN3": PCDN3,
+}
+
+var delafSubcat = map[string]byte{
+	"Hum":   HUMAN,
+	"Anl":   ANIMAL,
+	"Conc":  CONCRET,
+	"Abst":  ABSTRACT,
+	"Unit":  UNIT,
+	"Indef": INDEFINITE,
+	"Temp":  TEMPORAL,
+	"Dem":   DEMONSTRATIVE,
+}
+
+var delafFlags = map[string]byte{
+	"PR":   PROPER,
+	"Coll": COLLECTIVE,
+}
+
+var delafTense = map[rune]byte{
+	'W': INF,
+	'G': GERONDIF,
+	'K': PPAST,
+	'P': IND,
+	'I': IND,
+	'J': IND,
+	'F': IND,
+	'C': COND,
+	'S': SUBJ,
+	'T': SUBJ,
+	'Y': IMP,
+}
+
+// splits s at unescaped sep and removes escapes from the head
+func delafSplit(s string, sep string) (head string, tail string, found bool) {
+	var b strings.Builder
+	escaped := false
+	for i, r := range s {
+		if escaped {
+			b.WriteRune(r)
+			escaped = false
+			continue
+		}
+		if r == '\\' {
+			escaped = true
+			continue
+		}
+		if strings.ContainsRune(sep, r) {
+			return b.String(), s[i:], true
+		}
+		b.WriteRune(r)
+	}
+	return b.String(), "", false
+}
+
+// s up to its trailing comment, escapes are kept
+func delafStripComment(s string) string {
+	escaped := false
+	for i, r := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '/':
+			return s[:i]
+		}
+	}
+	return s
+}
+
+// ok is false for blank and comment lines
+func ParseDelafLine(line string) (entry DelafEntry, ok bool, err error) {
+	line = strings.TrimRight(strings.TrimPrefix(line, "\ufeff"), "\r\n")
+	if len(strings.TrimSpace(line)) == 0 || strings.HasPrefix(line, "/") {
+		return
+	}
+	// before the lemma, comments may hold dots
+	line = delafStripComment(line)
+
+	form, rest, found := delafSplit(line, ",")
+	if !found {
+		err = fmt.Errorf("missing ',' in '%s'", line)
+		return
+	}
+	entry.Form = form
+	rest = rest[1:]
+
+	lemma, codes, found := delafSplit(rest, ".")
+	if found {
+		entry.Lemma = lemma
+		codes = codes[1:]
+	} else {
+		// DELAS line, the form is the lemma
+		codes = rest
+	}
+	if len(entry.Lemma) == 0 {
+		entry.Lemma = entry.Form
+	}
+
+	gram, inflections, _ := delafSplit(codes, ":")
+	fields := strings.Split(gram, "+")
+	entry.Pos = fields[0]
+	if !found {
+		// strip DELAS inflection class N32 -> N
+		entry.Pos = strings.TrimRightFunc(entry.Pos, unicode.IsDigit)
+	}
+	if len(entry.Pos) == 0 {
+		err = fmt.Errorf("missing part of speech in '%s'", line)
+		return
+	}
+	entry.Semantic = fields[1:]
+
+	for len(inflections) > 0 {
+		var code string
+		code, inflections, _ = delafSplit(inflections[1:], ":")
+		if len(code) > 0 {
+			entry.Inflections = append(entry.Inflections, code)
+		}
+	}
+	ok = true
+	return
+}
+
+// one variant per inflection code, on error the variants are still
+// filled with unknown pos mapped to X and unknown codes dropped, err
+// is the first *VariantError met
+func (entry *DelafEntry) Variants(lang byte) (variants []WordVariant, err error) {
+	unknown := func(attribute string, value string) {
+		if err == nil {
+			err = &VariantError{entry.Lemma, entry.Form, attribute, value}
+		}
+	}
+
+	base := WordVariant{}
+	base.Language = lang
+	base.Lemma = entry.Lemma
+
+	tag, ok := delafPos[entry.Pos]
+	if name := findFeatureCode(tagNames, entry.Pos); !ok && name != nil {
+		// codes written by WriteDelaf for tags without DELAF pos
+		tag, ok = name.value, true
+	}
+	if !ok {
+		tag = X
+		unknown("pos", entry.Pos)
+	}
+	base.Tag = tag
+
+	for _, code := range entry.Semantic {
+		if subcat, ok := delafSubcat[code]; ok {
+			base.Subcat = subcat
+		} else if flag, ok := delafFlags[code]; ok {
+			base.Flags |= flag
+		} else if name := findFeatureCode(flagNames, code); name != nil {
+			base.Flags |= name.value
+		}
+		// other semantic codes have no WordVariant equivalent
+	}
+
+	if len(entry.Inflections) == 0 {
+		return []WordVariant{base}, err
+	}
+
+	for _, code := range entry.Inflections {
+		v := base
+		if !v.parseInflection(code) {
+			unknown("inflection", code)
+		}
+		variants = append(variants, v)
+	}
+	return
+}
+
+var ErrOddUTF16 = errors.New("utf-16 text of odd length")
+
+// utf-8 text of an utf-16 reader
+type utf16Reader struct {
+	r       *bufio.Reader
+	order   binary.ByteOrder
+	pending []byte // encoded and not read
+}
+
+func (u *utf16Reader) unit() (rune, error) {
+	var b [2]byte
+	_, err := io.ReadFull(u.r, b[:])
+	if err == io.ErrUnexpectedEOF {
+		err = ErrOddUTF16
+	}
+	return rune(u.order.Uint16(b[:])), err
+}
+
+func (u *utf16Reader) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if len(u.pending) == 0 {
+			r, err := u.unit()
+			if err == nil && utf16.IsSurrogate(r) {
+				var low rune
+				low, err = u.unit()
+				r = utf16.DecodeRune(r, low)
+			}
+			if err != nil {
+				if n > 0 && err == io.EOF {
+					return n, nil
+				}
+				return n, err
+			}
+			u.pending = utf8.AppendRune(u.pending[:0], r)
+		}
+		k := copy(p[n:], u.pending)
+		u.pending = u.pending[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// r as utf-8, unitex files are usually utf-16 with a byte order mark
+func delafReader(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	bom, _ := br.Peek(2)
+	switch {
+	case len(bom) == 2 && bom[0] == 0xff && bom[1] == 0xfe:
+		br.Discard(2)
+		return &utf16Reader{r: br, order: binary.LittleEndian}
+	case len(bom) == 2 && bom[0] == 0xfe && bom[1] == 0xff:
+		br.Discard(2)
+		return &utf16Reader{r: br, order: binary.BigEndian}
+	}
+	return br
+}
+
+// reads an utf-8 or an utf-16 DELAF/DELAS file
+func ReadDelaf(path string, lang byte, policy byte, addWord func(form string, word *Word) error) (summary ReadSummary, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(delafReader(f))
+	scanner.Buffer(make([]byte, 64*1024), maxRecordLen)
+	for line := 1; scanner.Scan(); line++ {
+		err = summary.addDelafLine(scanner.Text(), lang, policy, addWord)
+		if err != nil {
+			return summary, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+func (summary *ReadSummary) addDelafLine(line string, lang byte, policy byte, addWord func(form string, word *Word) error) error {
+	entry, ok, err := ParseDelafLine(line)
+	if err != nil || !ok {
+		return err
+	}
+	summary.Entries++
+
+	variants, verr := entry.Variants(lang)
+	keep, err := summary.apply(policy, verr)
+	if err != nil || !keep {
+		return err
+	}
+
+	word := Word{}
+	word.AddVariants(variants)
+	err = addWord(entry.Form, &word)
+	if err != nil {
+		return err
+	}
+	summary.Forms++
+	return nil
+}
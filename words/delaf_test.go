@@ -0,0 +1,129 @@
+package words
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+const testDelaf = `/ comment line
+chiennes,chien.N+Hum:fp
+aimons,aimer.V:P1p:Y1p
+porte\-avions,porte\-avion.N+Conc:mp
+M\.,monsieur.ABR:ms/abbreviation
+chat,N1+Anl
+`
+
+func TestParseDelafLine(t *testing.T) {
+	entry, ok, err := ParseDelafLine(`a\,b,c\.d.N+Hum+z1:ms:fs/comment`)
+	if err != nil || !ok {
+		t.Fatalf("cannot parse line %s", err)
+	}
+	if entry.Form != "a,b" || entry.Lemma != "c.d" || entry.Pos != "N" {
+		t.Errorf("bad entry %v", entry)
+	}
+	if len(entry.Semantic) != 2 || entry.Semantic[0] != "Hum" {
+		t.Errorf("bad semantic codes %v", entry.Semantic)
+	}
+	if len(entry.Inflections) != 2 || entry.Inflections[1] != "fs" {
+		t.Errorf("bad inflections %v", entry.Inflections)
+	}
+
+	_, ok, err = ParseDelafLine("/ comment")
+	if ok || err != nil {
+		t.Errorf("comment not skipped")
+	}
+	entry, ok, err = ParseDelafLine(`chat,N+z1/voir. aussi`)
+	if err != nil || !ok || entry.Lemma != "chat" || entry.Pos != "N" || entry.Semantic[0] != "z1" {
+		t.Errorf("dotted comment parsed as %v %s", entry, err)
+	}
+
+	_, _, err = ParseDelafLine("nocomma")
+	if err == nil {
+		t.Errorf("bad line accepted")
+	}
+}
+
+func TestReadDelaf(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.dic")
+	err := ioutil.WriteFile(path, []byte(testDelaf), 0666)
+	if err != nil {
+		t.Fatalf("cannot write delaf %s", err)
+	}
+
+	dict := Dictionary{}
+	_, err = dict.ReadLanguage(path, FRENCH, FAILUNKNOWN)
+	if err != nil {
+		t.Fatalf("cannot read delaf %s", err)
+	}
+
+	word, _ := dict.FindWord("chiennes")
+	expected := WordVariant{Tag: NOUN, Language: FRENCH, Subcat: HUMAN, Gender: FEMALE, Number: PLURAL, Lemma: "chien"}
+	if word == nil || len(word.Variants) != 1 || !word.Variants[0].Equals(&expected) {
+		t.Errorf("chiennes variant mismatch")
+	}
+
+	word, _ = dict.FindWord("aimons")
+	if word == nil || len(word.Variants) != 2 || word.Variants[0].Tense != IND || word.Variants[1].Tense != IMP {
+		t.Errorf("aimons variants mismatch")
+	}
+	if word.Variants[0].Person != 1 || word.Variants[0].Number != PLURAL {
+		t.Errorf("aimons person mismatch")
+	}
+
+	word, _ = dict.FindWord("porte-avions")
+	if word == nil || word.Variants[0].Lemma != "porte-avion" || word.Variants[0].Subcat != CONCRET {
+		t.Errorf("porte-avions not unescaped")
+	}
+
+	word, _ = dict.FindWord("M.")
+	if word == nil || !word.Tagged(ABBR) {
+		t.Errorf("M. not read")
+	}
+
+	word, _ = dict.FindWord("chat")
+	if word == nil || !word.Tagged(NOUN) || word.Variants[0].Lemma != "chat" || word.Variants[0].Subcat != ANIMAL {
+		t.Errorf("DELAS chat not read")
+	}
+
+	ioutil.WriteFile(path, []byte("chat,chat.N:mq\n"), 0666)
+	_, err = dict.ReadLanguage(path, FRENCH, FAILUNKNOWN)
+	if err == nil || err.Error() != path+":1: unknown inflection 'mq' for form 'chat' lemma 'chat'" {
+		t.Errorf("bad error %v", err)
+	}
+}
+
+func TestReadDelafUTF16(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		var b bytes.Buffer
+		for _, unit := range utf16.Encode([]rune("\ufeffété,été.N+Abst:ms\n𝄞,clef.N:ms\n")) {
+			binary.Write(&b, order, unit)
+		}
+		path := filepath.Join(t.TempDir(), "utf16.dic")
+		err := ioutil.WriteFile(path, b.Bytes(), 0666)
+		if err != nil {
+			t.Fatalf("cannot write delaf %s", err)
+		}
+
+		dict := Dictionary{}
+		_, err = dict.ReadLanguage(path, FRENCH, FAILUNKNOWN)
+		if err != nil {
+			t.Fatalf("cannot read %s delaf %s", order, err)
+		}
+		for _, form := range []string{"été", "𝄞"} {
+			if word, _ := dict.FindWord(form); word == nil || !word.Tagged(NOUN) {
+				t.Errorf("%s %s not read", order, form)
+			}
+		}
+
+		ioutil.WriteFile(path, b.Bytes()[:b.Len()-1], 0666)
+		_, err = dict.ReadLanguage(path, FRENCH, FAILUNKNOWN)
+		if !errors.Is(err, ErrOddUTF16) {
+			t.Errorf("truncated utf-16 gives %v", err)
+		}
+	}
+}
@@ -1,75 +1,75 @@
 package words
 
 import (
-	"bufio"
-	"io"
+	"fmt"
 	"math"
-	"os"
 	"strings"
+	"sync"
 	"unicode/utf8"
 )
 
 type Dictionary struct {
 	root       WordLetter
+	lemmas     map[string][]*Word
+	folded     map[string][]*Word
+	suffixes   *WordLetter // reversed forms, built by the first FindSuffix
+	suffixOnce sync.Once
 	MaxWordLen int
 	MaxTokens  int
+
+	Sources []string      // source files the dictionary was built from
+	Header  *BinaryHeader // set when loaded from lm.bin
+
+	// corpus counts used to rank suggestions and completions
+	Frequencies *Frequencies
+
+	// reports loading progress of source files
+	Progress func(path string, read int64, total int64, entries int)
+}
+
+// reads a .xml dela file or a DELAF/DELAS text file
+func (dict *Dictionary) ReadLanguage(path string, lang byte, policy byte) (summary ReadSummary, err error) {
+	format := FormatDelaf
+	if strings.HasSuffix(path, ".xml") {
+		format = FormatDelaXML
+	}
+	return dict.ReadFormat(path, format, lang, policy)
 }
 
-func (dict *Dictionary) ReadLanguage(path string, lang byte) (err error) {
-	words := WordList{}
-	err = words.Read(path, lang, func(form string, word *Word)(error){
+func (dict *Dictionary) ReadFormat(path string, format string, lang byte, policy byte) (summary ReadSummary, err error) {
+	return dict.readFormat(path, format, lang, policy, func(form string, word *Word) error {
 		dict.AddWord(form, word)
 		return nil
 	})
-	if err != nil {
-		return err
-	}
-	return
 }
 
-func (dict *Dictionary) ReadXML() error {
-	err := dict.ReadLanguage("lmdata/dela-fr-public-u8.dic.xml", FRENCH)
-	if err != nil {
-		panic(err)
-		return err
-	}
-	err = dict.ReadLanguage("lmdata/dela-addon-fr-u8.dic.xml", FRENCH)
-	if err != nil {
-		panic(err)
-		return err
-	}
-	err = dict.ReadLanguage("lmdata/dela-abbr-fr-u8.dic.xml", FRENCH)
-	if err != nil {
-		panic(err)
-		return err
-	}
-	err = dict.ReadLanguage("lmdata/dela-en-public-u8.dic.xml", ENGLISH)
-	if err != nil {
-		panic(err)
-		return err
-	}
-	err = dict.ReadLanguage("lmdata/dela-proper-u8.dic.xml", 0)
-	if err != nil {
-		panic(err)
-		return err
-	}
-	err = dict.ReadLanguage("lmdata/dela-acron-u8.dic.xml", 0)
-	if err != nil {
-		panic(err)
-		return err
+func (dict *Dictionary) readFormat(path string, format string, lang byte, policy byte, addWord func(string, *Word) error) (summary ReadSummary, err error) {
+	switch format {
+	case FormatDelaXML:
+		words := WordList{}
+		words.Policy = policy
+		if dict.Progress != nil {
+			words.Progress = func(read int64, total int64, entries int) {
+				dict.Progress(path, read, total, entries)
+			}
+		}
+		err = words.Read(path, lang, addWord)
+		summary = words.Summary
+	case FormatDelaf:
+		summary, err = ReadDelaf(path, lang, policy, addWord)
+	default:
+		err = fmt.Errorf("%s: unknown dictionary format '%s'", path, format)
 	}
-	err = dict.ReadLanguage("lmdata/dela-punc-u8.dic.xml", 0)
 	if err != nil {
-		panic(err)
-		return err
+		return
 	}
+	dict.Sources = append(dict.Sources, path)
+	return
+}
 
-	word := new(Word)
-	dict.AddWord("...", word)
-
-	dict.AddBuiltin()
-
-	return err
+// reads the sources of DefaultManifest
+func (dict *Dictionary) ReadXML(policy byte) (summary ReadSummary, err error) {
+	return dict.readManifest(DefaultManifest(), policy)
 }
 
 func (dict *Dictionary) AddWordWithTag(s string, tag byte) {
@@ -101,11 +101,82 @@ func (dict *Dictionary) AddBuiltin() {
 }
 
 func (dict *Dictionary) AddWord(letters string, word *Word) {
-	dict.root.AddWord(letters, word)
+	stored := dict.root.AddWord(letters, word)
+	dict.addLemmas(stored, word.Variants)
+	dict.addFolded(letters, stored)
+	if dict.suffixes != nil {
+		dict.addSuffix(letters, stored)
+	}
 	dict.MaxWordLen = int(math.Max(float64(len(letters)), float64(dict.MaxWordLen)))
 	dict.MaxTokens = int(math.Max(float64(strings.Count(letters, " ")), float64(dict.MaxTokens)))
 }
 
+func (dict *Dictionary) addLemmas(word *Word, variants []WordVariant) {
+	if dict.lemmas == nil {
+		dict.lemmas = make(map[string][]*Word)
+	}
+	for _, v := range variants {
+		if len(v.Lemma) == 0 {
+			continue
+		}
+		found := false
+		for _, w := range dict.lemmas[v.Lemma] {
+			if w == word {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dict.lemmas[v.Lemma] = append(dict.lemmas[v.Lemma], word)
+		}
+	}
+}
+
+func (dict *Dictionary) Lemmatize(form string) (lemmas []string) {
+	word, _ := dict.FindWord(form)
+	if word == nil {
+		return
+	}
+	for _, v := range word.Variants {
+		if len(v.Lemma) == 0 {
+			continue
+		}
+		found := false
+		for _, lemma := range lemmas {
+			if lemma == v.Lemma {
+				found = true
+				break
+			}
+		}
+		if !found {
+			lemmas = append(lemmas, v.Lemma)
+		}
+	}
+	return
+}
+
+func (dict *Dictionary) Inflect(lemma string, target *WordVariant) (forms []string) {
+	filter := WordVariant{}
+	if target != nil {
+		filter = *target
+	}
+	filter.Lemma = lemma
+
+	for _, word := range dict.lemmas[lemma] {
+		for i := range word.Variants {
+			if filter.Matches(&word.Variants[i]) {
+				forms = append(forms, word.String())
+				break
+			}
+		}
+	}
+	return
+}
+
+func (dict *Dictionary) Limits() (int, int) {
+	return dict.MaxWordLen, dict.MaxTokens
+}
+
 func (dict *Dictionary) FindWord(letters string) (*Word, bool) {
 	return dict.root.FindWord(letters)
 }
@@ -118,48 +189,60 @@ func (dict *Dictionary) FindPath(letters string) *WordLetter {
 	return dict.root.FindPath(letters)
 }
 
+// words of the same length as word with at most maxerror different
+// letters by position, see Suggest for edit distances
 func (dict *Dictionary) FindAlternatives(word string, lang byte, maxerror int) []*Word {
 	var words []*Word
 
-	wordch := make(chan *Word)
-	count := utf8.RuneCountInString(word)
-	go dict.WalkOfSize(count, wordch)
-
-	for {
-		w, ok := <-wordch
-		if ok {
-			errors := CountError(word, w.String())
-			if errors <= maxerror {
-				if w.Language(lang) {
-					words = append(words, w)
-				}
-			}
-		} else {
-			break
+	dict.Each(WalkOptions{Size: utf8.RuneCountInString(word)}, func(w *Word) bool {
+		if CountError(word, w.String()) <= maxerror && w.Language(lang) {
+			words = append(words, w)
 		}
-	}
+		return true
+	})
 	return words
 }
 
 func (dict *Dictionary) AutoComplete(word string, filter *WordVariant) []*Word {
 	var words []*Word
 
-	wordch := make(chan *Word)
-	go dict.WalkFromPath(word, wordch)
+	if len(word) == 0 {
+		return nil
+	}
+	dict.Each(WalkOptions{Prefix: word}, func(w *Word) bool {
+		if filter == nil || filter.Filter(w) {
+			words = append(words, w)
+		}
+		return true
+	})
+	dict.Frequencies.SortWords(words)
+	return words
+}
 
-	for {
-		w, ok := <-wordch
-		if ok {
-			if filter == nil || filter.Filter(w) {
-				words = append(words, w)
-			}
-		} else {
-			break
+// variants of word, most frequent lemma first
+func (dict *Dictionary) RankVariants(word *Word) []WordVariant {
+	return dict.Frequencies.RankVariants(word)
+}
+
+// calls fn on the words within options in rune order until fn
+// returns false
+func (dict *Dictionary) Each(options WalkOptions, fn func(*Word) bool) {
+	size, depth, ok := options.bounds()
+	if !ok {
+		return
+	}
+	letter := &dict.root
+	if len(options.Prefix) > 0 {
+		letter = dict.FindPath(options.Prefix)
+		if letter == nil {
+			return
 		}
 	}
-	return words
+	letter.each(size, depth, fn)
 }
 
+// channel walks, the caller runs them in a goroutine and must read
+// every word, Each can stop early
 func (dict *Dictionary) Walk(wordch chan *Word) {
 	dict.root.Walk(wordch)
 	close(wordch)
@@ -180,53 +263,3 @@ func (dict *Dictionary) WalkFromPath(word string, wordch chan *Word) {
 	close(wordch)
 	return
 }
-
-func (dict *Dictionary) WriteBinary(path string) (err error) {
-	wordch := make(chan *Word)
-
-	f, err := os.Create(path)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	w := bufio.NewWriter(f)
-	defer w.Flush()
-
-	go dict.Walk(wordch)
-	for {
-		word, ok := <-wordch
-		if ok {
-			word.Write(w)
-		} else {
-			break
-		}
-
-	}
-	return
-}
-
-func (dict *Dictionary) ReadBinary(path string) (err error) {
-	f, err := os.Open(path)
-	if err != nil {
-		return err
-	}
-
-	r := bufio.NewReaderSize(f, 1024*1024)
-	for {
-		word := Word{}
-		letters, err := word.Read(r)
-
-		if err == nil {
-			dict.AddWord(letters, &word)
-		} else {
-			if err != io.EOF {
-				return err
-			} else {
-				err = nil
-				break
-			}
-		}
-	}
-	return err
-}
@@ -1,13 +1,15 @@
 package words
 
 import (
+	"sort"
+	"strings"
 	"testing"
 	"unicode/utf8"
 )
 
 func GetDictionary() *Dictionary {
 	context := GetTokenizeContext()
-	return context.dict
+	return context.GetDictionary()
 }
 
 func TestAddWord(t *testing.T) {
@@ -208,3 +210,221 @@ func TestAutoCompleteFilter(t *testing.T) {
 		t.Errorf("lance not found")
 	}
 }
+
+func GetLemmaDictionary() *Dictionary {
+	dict := Dictionary{}
+
+	forms := []struct {
+		form   string
+		gender byte
+		number byte
+	}{
+		{"chien", MALE, SINGULAR},
+		{"chiens", MALE, PLURAL},
+		{"chienne", FEMALE, SINGULAR},
+		{"chiennes", FEMALE, PLURAL},
+	}
+	for _, f := range forms {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Gender: f.gender, Number: f.number, Lemma: "chien"})
+		dict.AddWord(f.form, &word)
+	}
+	return &dict
+}
+
+func TestLemmatize(t *testing.T) {
+	dict := GetLemmaDictionary()
+
+	lemmas := dict.Lemmatize("chiennes")
+	if len(lemmas) != 1 || lemmas[0] != "chien" {
+		t.Errorf("chiennes lemma mismatch %v", lemmas)
+	}
+
+	lemmas = dict.Lemmatize("chat")
+	if len(lemmas) != 0 {
+		t.Errorf("chat has lemmas %v", lemmas)
+	}
+}
+
+func TestInflect(t *testing.T) {
+	dict := GetLemmaDictionary()
+
+	target := WordVariant{Gender: FEMALE, Number: PLURAL}
+	forms := dict.Inflect("chien", &target)
+	if len(forms) != 1 || forms[0] != "chiennes" {
+		t.Errorf("chien fem plural mismatch %v", forms)
+	}
+
+	forms = dict.Inflect("chien", nil)
+	if len(forms) != 4 {
+		t.Errorf("chien has %d forms", len(forms))
+	}
+}
+
+func TestBinaryLemma(t *testing.T) {
+	writedict := GetLemmaDictionary()
+
+	err := writedict.WriteBinary("test.bin")
+	if err != nil {
+		t.Errorf("cannot write binary %s", err)
+	}
+
+	readdict := Dictionary{}
+	err = readdict.ReadBinary("test.bin")
+	if err != nil {
+		t.Errorf("cannot read binary %s", err)
+	}
+
+	lemmas := readdict.Lemmatize("chiennes")
+	if len(lemmas) != 1 || lemmas[0] != "chien" {
+		t.Errorf("chiennes lemma not read %v", lemmas)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	dict := Dictionary{}
+	for _, form := range []string{"appel", "appels", "recevoir", "test", "teste", "école"} {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH})
+		dict.AddWord(form, &word)
+	}
+	english := Word{}
+	english.Variants = append(english.Variants, WordVariant{Tag: NOUN, Language: ENGLISH})
+	dict.AddWord("apple", &english)
+
+	misspelled := []struct {
+		word    string
+		correct string
+	}{
+		{"apel", "appel"},
+		{"recevoire", "recevoir"},
+		{"tset", "test"},
+		{"ecole", "école"},
+	}
+	for _, suggester := range []Suggester{&dict, GetCompactDictionary(t, &dict)} {
+		for _, m := range misspelled {
+			suggestions := suggester.Suggest(m.word, FRENCH, 2, 1)
+			if len(suggestions) != 1 || suggestions[0].Word.String() != m.correct {
+				t.Errorf("%T: %s should suggest %s", suggester, m.word, m.correct)
+			}
+		}
+
+		suggestions := suggester.Suggest("apel", FRENCH, 2, 0)
+		if len(suggestions) != 2 || suggestions[1].Word.String() != "appels" || suggestions[1].Distance != 2 {
+			t.Errorf("%T: apel suggestions not ranked", suggester)
+		}
+
+		suggestions = suggester.Suggest("aple", 0, 1, 0)
+		if len(suggestions) != 1 || suggestions[0].Word.String() != "apple" {
+			t.Errorf("%T: aple should suggest apple in any language", suggester)
+		}
+		suggestions = suggester.Suggest("aple", FRENCH, 1, 0)
+		if len(suggestions) != 0 {
+			t.Errorf("%T: aple suggests english words in french", suggester)
+		}
+	}
+}
+
+func TestFindAlternativeSize(t *testing.T) {
+	dict := Dictionary{}
+	for _, form := range []string{"test", "teste", "tes", "tost", "été"} {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH})
+		dict.AddWord(form, &word)
+	}
+	dict.AddWord("tust", &Word{})
+
+	// letters are compared by position, only words of the same length
+	var forms []string
+	for _, w := range dict.FindAlternatives("tast", FRENCH, 1) {
+		forms = append(forms, w.String())
+	}
+	if strings.Join(forms, " ") != "test tost" {
+		t.Errorf("tast alternatives %v", forms)
+	}
+	alternatives := dict.FindAlternatives("éte", FRENCH, 1)
+	if len(alternatives) != 1 || alternatives[0].String() != "été" {
+		t.Errorf("éte alternatives %v", alternatives)
+	}
+}
+
+func TestFindFolded(t *testing.T) {
+	dict := Dictionary{}
+	for _, form := range []string{"côté", "coté", "cote", "à"} {
+		dict.AddWord(form, &Word{})
+	}
+
+	words := dict.FindFolded("COTE")
+	if len(words) != 3 || words[0].String() != "cote" {
+		t.Errorf("COTE folded candidates %d", len(words))
+	}
+	words = dict.FindFolded("a")
+	if len(words) != 1 || words[0].String() != "à" {
+		t.Errorf("a folded candidates %d", len(words))
+	}
+	// found in the automaton of a compact dictionary
+	compact := GetCompactDictionary(t, &dict)
+	sorted := func(words []*Word) string {
+		forms := strings.Fields(SuffixForms(words))
+		sort.Strings(forms)
+		return strings.Join(forms, " ")
+	}
+	for _, s := range []string{"COTE", "côte", "a", "b"} {
+		expected := sorted(dict.FindFolded(s))
+		if forms := sorted(compact.FindFolded(s)); forms != expected {
+			t.Errorf("%s compact folded candidates '%s' not '%s'", s, forms, expected)
+		}
+	}
+	words = compact.FindFolded("COTE")
+	if len(words) != 3 || words[0].String() != "cote" {
+		t.Errorf("COTE compact folded candidates %d", len(words))
+	}
+
+	if Fold("Élève") != "eleve" {
+		t.Errorf("bad fold %s", Fold("Élève"))
+	}
+}
+
+func CollectEach(lexicon Lexicon, options WalkOptions) (words []string) {
+	lexicon.Each(options, func(w *Word) bool {
+		words = append(words, w.String())
+		return true
+	})
+	return
+}
+
+func TestEach(t *testing.T) {
+	dict := GetCompactTestDictionary()
+
+	words := CollectEach(dict, WalkOptions{})
+	if len(words) != 11 || !sort.StringsAreSorted(words) {
+		t.Errorf("walk not sorted %v", words)
+	}
+
+	expected := []struct {
+		options WalkOptions
+		words   string
+	}{
+		{WalkOptions{Prefix: "chien"}, "chien chienne chiennes chiens"},
+		{WalkOptions{Prefix: "chien", Depth: 1}, "chien chiens"},
+		{WalkOptions{Prefix: "chien", Size: 6}, "chiens"},
+		{WalkOptions{Size: 5}, "chien lance"},
+		{WalkOptions{Prefix: "lancer", Size: 5}, ""},
+		{WalkOptions{Prefix: "chat"}, ""},
+	}
+	for _, e := range expected {
+		words := strings.Join(CollectEach(dict, e.options), " ")
+		if words != e.words {
+			t.Errorf("%+v gives '%s' not '%s'", e.options, words, e.words)
+		}
+	}
+
+	var first []string
+	dict.Each(WalkOptions{}, func(w *Word) bool {
+		first = append(first, w.String())
+		return len(first) < 3
+	})
+	if strings.Join(first, " ") != "chien chienne chiennes" {
+		t.Errorf("walk not stopped %v", first)
+	}
+}
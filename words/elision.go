@@ -0,0 +1,118 @@
+package words
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// french elided words with their apostrophe, used before a vowel when
+// the lexicon has no such form, the variants are flagged ELIDED
+var elisions = map[string][]WordVariant{
+	"l'":      {{Tag: DET, Number: SINGULAR, Lemma: "le"}, {Tag: PRONOUN, Person: 3, Number: SINGULAR, Lemma: "le"}},
+	"d'":      {{Tag: PREP, Lemma: "de"}, {Tag: DET, Lemma: "de"}},
+	"j'":      {{Tag: PRONOUN, Person: 1, Number: SINGULAR, Lemma: "je"}},
+	"m'":      {{Tag: PRONOUN, Person: 1, Number: SINGULAR, Lemma: "me"}},
+	"t'":      {{Tag: PRONOUN, Person: 2, Number: SINGULAR, Lemma: "te"}},
+	"s'":      {{Tag: PRONOUN, Person: 3, Lemma: "se"}, {Tag: CONJS, Lemma: "si"}},
+	"n'":      {{Tag: ADVERB, Lemma: "ne"}},
+	"c'":      {{Tag: PRONOUN, Subcat: DEMONSTRATIVE, Lemma: "ce"}},
+	"ç'":      {{Tag: PRONOUN, Subcat: DEMONSTRATIVE, Lemma: "ce"}},
+	"qu'":     {{Tag: CONJS, Lemma: "que"}, {Tag: PRONOUN, Lemma: "que"}},
+	"jusqu'":  {{Tag: PREP, Lemma: "jusque"}},
+	"lorsqu'": {{Tag: CONJS, Lemma: "lorsque"}},
+	"puisqu'": {{Tag: CONJS, Lemma: "puisque"}},
+	"quoiqu'": {{Tag: CONJS, Lemma: "quoique"}},
+}
+
+var elisionWords = func() map[string]*Word {
+	words := make(map[string]*Word)
+	for form, variants := range elisions {
+		word := Word{}
+		word.form = form
+		for _, v := range variants {
+			v.Language = FRENCH
+			v.Flags |= ELIDED
+			word.Variants = append(word.Variants, v)
+		}
+		words[form] = &word
+	}
+	return words
+}()
+
+// typographic apostrophes are looked up as '
+var apostropheReplacer = strings.NewReplacer("’", "'", "ʼ", "'")
+
+func TokenizeIsApostrophe(r rune) bool {
+	return r == '\'' || r == '’' || r == 'ʼ'
+}
+
+func TokenizeNormalizeApostrophes(s string) string {
+	return apostropheReplacer.Replace(s)
+}
+
+// french elides before a vowel or a mute h only, the n' of rock'n'roll
+// is not french
+func elisionBefore(next string) bool {
+	r, _ := utf8.DecodeRuneInString(next)
+	return strings.ContainsRune("aeiouyhàâäéèêëîïôöùûüÿæœ", unicode.ToLower(r))
+}
+
+// word with its variants flagged ELIDED, copied when some are not
+func elidedWord(word *Word) *Word {
+	for _, v := range word.Variants {
+		if v.Flags&ELIDED == 0 {
+			elided := *word
+			elided.Variants = make([]WordVariant, len(word.Variants))
+			for i, v := range word.Variants {
+				v.Flags |= ELIDED
+				elided.Variants[i] = v
+			}
+			return &elided
+		}
+	}
+	return word
+}
+
+// the elided word s ending with an apostrophe and followed by next,
+// from the lexicon or from the french elisions when next starts with a
+// vowel or an h
+func TokenizeFindElided(s string, next string, context *TokenizeContext) *Word {
+	word, _ := TokenizeFindWord(s, context)
+	if word != nil {
+		return elidedWord(word)
+	}
+	if !elisionBefore(next) {
+		return nil
+	}
+	return elisionWords[TokenizeToLower(TokenizeNormalizeApostrophes(s))]
+}
+
+// token of the word started at start and ending at the apostrophe at i:
+// the whole lexicalized word as aujourd'hui or presqu'île, else the
+// elided word as l' or qu', nil when the apostrophe is a separator
+func TokenizeElision(content string, start int, i int, context *TokenizeContext) *Token {
+	_, w := utf8.DecodeRuneInString(content[i:])
+	end := i + w
+	for end < len(content) {
+		r, l := utf8.DecodeRuneInString(content[end:])
+		if !unicode.IsLetter(r) {
+			break
+		}
+		end += l
+	}
+
+	first, _ := utf8.DecodeRuneInString(content[start:])
+	if end > i+w {
+		word, _ := TokenizeFindWord(content[start:end], context)
+		if word != nil {
+			return &Token{Pos: []int{start, end}, Word: word, IsUpper: unicode.IsUpper(first)}
+		}
+	}
+
+	word := TokenizeFindElided(content[start:i+w], content[i+w:], context)
+	if word == nil {
+		return nil
+	}
+	return &Token{Pos: []int{start, i + w}, Word: word, IsUpper: unicode.IsUpper(first)}
+}
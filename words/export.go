@@ -0,0 +1,348 @@
+package words
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// export formats besides FormatDelaXML and FormatDelaf, only these two
+// are read back
+const (
+	FormatJSONL = "jsonl" // one lemma per line
+	FormatCSV   = "csv"   // one variant per row
+)
+
+// forms of a lemma, Variant holds the features shared by the forms:
+// lemma, tag, language, subcat and flags
+type LemmaEntry struct {
+	Variant WordVariant
+	Forms   []LemmaForm
+}
+
+type LemmaForm struct {
+	Form     string
+	Variants []WordVariant
+}
+
+// names of the features of the dela xml files, the reverse of
+// Inflected.GetVariant
+var delaSubcatNames = map[byte]string{
+	HUMAN:         "human",
+	ANIMAL:        "animal",
+	CONCRET:       "concret",
+	ABSTRACT:      "abstract",
+	UNIT:          "unit",
+	INDEFINITE:    "indefinite",
+	TEMPORAL:      "temporal",
+	DEMONSTRATIVE: "demonstrative",
+}
+
+var delaFlagFeats = []struct {
+	flag byte
+	feat Feat
+}{
+	{PROPER, Feat{"proper", "true"}},
+	{COMPOUND, Feat{"compound", "comp"}},
+	{COLL, Feat{"coll", "true"}},
+	{POSTPOS, Feat{"postpos", "true"}},
+	{COLLECTIVE, Feat{"collective", "true"}},
+	{PROCATDEMONSTRATIVE, Feat{"procat", "demonstrative"}},
+}
+
+var delaTenseNames = map[byte]string{
+	IND:      "ind",
+	GERONDIF: "gerondif",
+	SUBJ:     "subj",
+	PPAST:    "ppast",
+	IMP:      "imp",
+	COND:     "cond",
+	INF:      "inf",
+}
+
+var delaGenderNames = map[byte]string{MALE: "masculine", FEMALE: "feminine"}
+var delaNumberNames = map[byte]string{SINGULAR: "singular", PLURAL: "plural"}
+
+// dela escapes of a DELAF form
+var delafFormEscaper = strings.NewReplacer(`\`, `\\`, `,`, `\,`, `/`, `\/`)
+
+// the lemma features of v
+func (v *WordVariant) lemmaVariant() WordVariant {
+	return WordVariant{Tag: v.Tag, Language: v.Language, Flags: v.Flags, Subcat: v.Subcat, Lemma: v.Lemma}
+}
+
+// forms of lexicon grouped by lemma in lemma then tag order, forms in
+// rune order, only the variants of lang unless it is 0, variants
+// without lemma as the builtin punctuation are left out
+func LemmaEntries(lexicon Lexicon, lang byte) []LemmaEntry {
+	var entries []LemmaEntry
+	index := make(map[WordVariant]int)
+
+	lexicon.Each(WalkOptions{}, func(w *Word) bool {
+		for _, v := range w.Variants {
+			if len(v.Lemma) == 0 || (lang != 0 && v.Language != lang) {
+				continue
+			}
+			key := v.lemmaVariant()
+			i, ok := index[key]
+			if !ok {
+				i = len(entries)
+				index[key] = i
+				entries = append(entries, LemmaEntry{Variant: key})
+			}
+			entry := &entries[i]
+			if n := len(entry.Forms); n == 0 || entry.Forms[n-1].Form != w.String() {
+				entry.Forms = append(entry.Forms, LemmaForm{Form: w.String()})
+			}
+			form := &entry.Forms[len(entry.Forms)-1]
+			form.Variants = append(form.Variants, v)
+		}
+		return true
+	})
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Variant.Lemma != entries[j].Variant.Lemma {
+			return entries[i].Variant.Lemma < entries[j].Variant.Lemma
+		}
+		return entries[i].Variant.Tag < entries[j].Variant.Tag
+	})
+	return entries
+}
+
+// dela xml entry, one inflected element per variant
+func (entry *LemmaEntry) Entry() (e Entry, err error) {
+	base := &entry.Variant
+	e.Lemma = base.Lemma
+	for name, tag := range delaPos {
+		if tag == base.Tag && len(name) > 0 {
+			e.Tag.Name = name
+		}
+	}
+	if len(e.Tag.Name) == 0 && base.Tag != 0 {
+		return e, fmt.Errorf("lemma '%s': no dela xml pos for %s", base.Lemma, TagCode(base.Tag))
+	}
+	if name, ok := delaSubcatNames[base.Subcat]; ok {
+		e.Feats = append(e.Feats, Feat{"subcat", name})
+	}
+	for _, f := range delaFlagFeats {
+		if base.Flags&f.flag != 0 {
+			e.Feats = append(e.Feats, f.feat)
+		}
+	}
+
+	for _, form := range entry.Forms {
+		for _, v := range form.Variants {
+			inflected := Inflected{Form: form.Form}
+			if name, ok := delaGenderNames[v.Gender]; ok {
+				inflected.Feats = append(inflected.Feats, Feat{"gender", name})
+			}
+			if name, ok := delaNumberNames[v.Number]; ok {
+				inflected.Feats = append(inflected.Feats, Feat{"number", name})
+			}
+			if v.Person != 0 {
+				inflected.Feats = append(inflected.Feats, Feat{"person", fmt.Sprintf("%d", v.Person)})
+			}
+			if name, ok := delaTenseNames[v.Tense]; ok {
+				inflected.Feats = append(inflected.Feats, Feat{"tense", name})
+			}
+			e.Inflections = append(e.Inflections, inflected)
+		}
+	}
+	return
+}
+
+// word list of the lemmas of lexicon in lang, 0 for every language
+func NewWordList(lexicon Lexicon, lang byte) (*WordList, error) {
+	words := WordList{}
+	for _, entry := range LemmaEntries(lexicon, lang) {
+		e, err := entry.Entry()
+		if err != nil {
+			return nil, err
+		}
+		words.Entries = append(words.Entries, e)
+	}
+	return &words, nil
+}
+
+func (words *WordList) Encode(w io.Writer) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent(" ", " ")
+	err = encoder.Encode(words)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// one line per form and lemma: chiens,chien.N:mp, the inflections of
+// the form are joined chante,chanter.V:P1s:P3s:S1s:S3s:Y2s
+func WriteDelaf(w io.Writer, entries []LemmaEntry) error {
+	for _, entry := range entries {
+		base := entry.Variant
+		// the language is given when reading
+		base.Language = 0
+		codes := base.String()
+
+		for _, form := range entry.Forms {
+			if strings.ContainsAny(form.Form, "\r\n") {
+				return fmt.Errorf("form %q cannot be written as DELAF", form.Form)
+			}
+			var inflections []string
+			bare := false
+			for _, v := range form.Variants {
+				inflection := v.inflection()
+				if len(inflection) == 0 {
+					bare = true
+				} else {
+					inflections = append(inflections, inflection)
+				}
+			}
+
+			line := delafFormEscaper.Replace(form.Form) + "," + codes
+			if bare {
+				_, err := fmt.Fprintln(w, line)
+				if err != nil {
+					return err
+				}
+			}
+			if len(inflections) > 0 {
+				_, err := fmt.Fprintln(w, line+":"+strings.Join(inflections, ":"))
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+type jsonLemma struct {
+	Lemma    string     `json:"lemma"`
+	Pos      string     `json:"pos"`
+	Codes    []string   `json:"codes,omitempty"`
+	Language string     `json:"language,omitempty"`
+	Forms    []jsonForm `json:"forms"`
+}
+
+type jsonForm struct {
+	Form        string   `json:"form"`
+	Inflections []string `json:"inflections,omitempty"`
+}
+
+// one object per lemma with the DELAF codes of the features
+func WriteJSONL(w io.Writer, entries []LemmaEntry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, entry := range entries {
+		base := &entry.Variant
+		lemma := jsonLemma{Lemma: base.Lemma, Pos: TagCode(base.Tag), Codes: base.codes()}
+		if name := findFeatureName(languageNames, base.Language); name != nil {
+			lemma.Language = name.code
+		}
+		for _, form := range entry.Forms {
+			f := jsonForm{Form: form.Form}
+			for _, v := range form.Variants {
+				if inflection := v.inflection(); len(inflection) > 0 {
+					f.Inflections = append(f.Inflections, inflection)
+				}
+			}
+			lemma.Forms = append(lemma.Forms, f)
+		}
+		err := encoder.Encode(&lemma)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// one row per variant with the UD columns of the variant
+func WriteCSV(w io.Writer, entries []LemmaEntry) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"form", "lemma", "pos", "codes", "inflection", "language", "upos", "feats"})
+	for _, entry := range entries {
+		base := &entry.Variant
+		language := ""
+		if name := findFeatureName(languageNames, base.Language); name != nil {
+			language = name.code
+		}
+		for _, form := range entry.Forms {
+			for _, v := range form.Variants {
+				writer.Write([]string{form.Form, base.Lemma, TagCode(base.Tag), strings.Join(base.codes(), "+"),
+					v.inflection(), language, v.UPOS(), v.FEATS()})
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writes the lemmas of lexicon in lang, 0 for every language, xml and
+// delaf carry no language so reading them back needs one file per
+// language as in the manifest sources
+func Export(lexicon Lexicon, w io.Writer, format string, lang byte) error {
+	switch format {
+	case FormatDelaXML:
+		words, err := NewWordList(lexicon, lang)
+		if err != nil {
+			return err
+		}
+		return words.Encode(w)
+	case FormatDelaf:
+		return WriteDelaf(w, LemmaEntries(lexicon, lang))
+	case FormatJSONL:
+		return WriteJSONL(w, LemmaEntries(lexicon, lang))
+	case FormatCSV:
+		return WriteCSV(w, LemmaEntries(lexicon, lang))
+	}
+	return fmt.Errorf("unknown export format '%s'", format)
+}
+
+// format of an export path: .xml, .jsonl, .csv and delaf otherwise
+func ExportFormat(path string) string {
+	switch filepath.Ext(path) {
+	case ".xml":
+		return FormatDelaXML
+	case ".jsonl":
+		return FormatJSONL
+	case ".csv":
+		return FormatCSV
+	}
+	return FormatDelaf
+}
+
+// Export to path, format is guessed from path when empty
+func ExportFile(lexicon Lexicon, path string, format string, lang byte) (err error) {
+	if len(format) == 0 {
+		format = ExportFormat(path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return
+	}
+	defer func() {
+		cerr := f.Close()
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	err = Export(lexicon, w, format, lang)
+	if err != nil {
+		return
+	}
+	return w.Flush()
+}
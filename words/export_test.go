@@ -0,0 +1,145 @@
+package words
+
+import (
+	"bytes"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func GetExportDictionary() *Dictionary {
+	dict := GetFeatureDictionary()
+	dict.AddBuiltin()
+
+	words := []struct {
+		form    string
+		variant WordVariant
+	}{
+		{"chienne", WordVariant{Tag: NOUN, Subcat: ANIMAL, Flags: COLLECTIVE, Gender: FEMALE, Number: SINGULAR, Lemma: "chienne"}},
+		{"porte-avions", WordVariant{Tag: NOUN, Flags: COMPOUND, Gender: MALE, Lemma: "porte-avions"}},
+		{"aimer", WordVariant{Tag: VERB, Tense: INF, Lemma: "aimer"}},
+		{"M.", WordVariant{Tag: ABBR, Lemma: "M."}},
+		{"a,b", WordVariant{Tag: X, Lemma: "a,b"}},
+	}
+	for _, w := range words {
+		word := Word{}
+		w.variant.Language = FRENCH
+		word.Variants = append(word.Variants, w.variant)
+		dict.AddWord(w.form, &word)
+	}
+	return dict
+}
+
+// form and variants of every word with a lemma
+func CollectVariants(lexicon Lexicon) (variants []string) {
+	lexicon.Each(WalkOptions{}, func(w *Word) bool {
+		for _, v := range w.Variants {
+			if len(v.Lemma) > 0 {
+				variants = append(variants, w.String()+","+v.String())
+			}
+		}
+		return true
+	})
+	sort.Strings(variants)
+	return
+}
+
+func TestExportRoundTrip(t *testing.T) {
+	dict := GetExportDictionary()
+	expected := CollectVariants(dict)
+	dir := t.TempDir()
+
+	// through lm.bin as a dictionary and as a compact dictionary
+	bin := filepath.Join(dir, "lm.bin")
+	err := dict.WriteBinary(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := Dictionary{}
+	err = loaded.ReadBinary(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, lexicon := range []Lexicon{&loaded, GetCompactDictionary(t, dict)} {
+		for _, format := range []string{FormatDelaXML, FormatDelaf} {
+			path := filepath.Join(dir, "export."+format)
+			err = ExportFile(lexicon, path, format, FRENCH)
+			if err != nil {
+				t.Fatal(err)
+			}
+			read := Dictionary{}
+			_, err = read.ReadFormat(path, format, FRENCH, FAILUNKNOWN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			variants := CollectVariants(&read)
+			if strings.Join(variants, "\n") != strings.Join(expected, "\n") {
+				t.Errorf("%s does not round trip\n%v\n%v", format, variants, expected)
+			}
+		}
+	}
+}
+
+func TestLemmaEntries(t *testing.T) {
+	entries := LemmaEntries(GetExportDictionary(), 0)
+	var lemmas []string
+	for _, e := range entries {
+		lemmas = append(lemmas, e.Variant.String())
+	}
+	expected := "M\\..ABBR+fr a\\,b.X+fr aimer.V+fr belle.A+PR+fr chien.N+fr chienne.N+Anl+Coll+fr finir.V+fr porte-avions.N+Comp+fr"
+	if strings.Join(lemmas, " ") != expected {
+		t.Errorf("bad lemmas %v", lemmas)
+	}
+
+	aimer := entries[2]
+	if len(aimer.Forms) != 4 || aimer.Forms[0].Form != "aime" || len(aimer.Forms[0].Variants) != 4 {
+		t.Errorf("bad aimer forms %+v", aimer.Forms)
+	}
+
+	if len(LemmaEntries(GetExportDictionary(), ENGLISH)) != 0 {
+		t.Errorf("english lemmas")
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	dict := GetLemmaDictionary()
+
+	var buf bytes.Buffer
+	err := Export(dict, &buf, FormatDelaf, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "chien,chien.N:ms\nchienne,chien.N:fs\nchiennes,chien.N:fp\nchiens,chien.N:mp\n" {
+		t.Errorf("bad delaf\n%s", buf.String())
+	}
+
+	buf.Reset()
+	err = Export(dict, &buf, FormatJSONL, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"lemma":"chien","pos":"N","language":"fr","forms":[{"form":"chien","inflections":["ms"]},{"form":"chienne","inflections":["fs"]},{"form":"chiennes","inflections":["fp"]},{"form":"chiens","inflections":["mp"]}]}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("bad jsonl\n%s", buf.String())
+	}
+
+	buf.Reset()
+	err = Export(dict, &buf, FormatCSV, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 6 || lines[1] != "chien,chien,N,,ms,fr,NOUN,Gender=Masc|Number=Sing" {
+		t.Errorf("bad csv\n%s", buf.String())
+	}
+
+	err = Export(dict, &buf, "yaml", 0)
+	if err == nil {
+		t.Errorf("unknown format")
+	}
+	if ExportFormat("lm.csv") != FormatCSV || ExportFormat("lm.dic") != FormatDelaf {
+		t.Errorf("bad export formats")
+	}
+}
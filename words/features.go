@@ -0,0 +1,232 @@
+package words
+
+import (
+	"sort"
+)
+
+// variant features indexed by FeatureIndex
+const (
+	FeatureTag = iota
+	FeatureLanguage
+	FeatureGender
+	FeatureNumber
+	FeaturePerson
+	FeatureTense
+	FeatureSubcat
+	FeatureFlags // one posting list per flag bit
+	featureCount
+)
+
+// inverted index from variant features to the variants of a lexicon,
+// queries are WordVariant whose zero fields match any value as in
+// WordVariant.Matches and every constraint holds on the same variant
+//
+// the index is built once from a walk of the lexicon and is not
+// updated by later AddWord
+type FeatureIndex struct {
+	variants []FeatureMatch
+	postings [featureCount]map[byte][]int32 // feature value -> variant ids
+}
+
+type FeatureMatch struct {
+	Word    *Word
+	Variant *WordVariant
+}
+
+func featureValue(v *WordVariant, feature int) byte {
+	switch feature {
+	case FeatureTag:
+		return v.Tag
+	case FeatureLanguage:
+		return v.Language
+	case FeatureGender:
+		return v.Gender
+	case FeatureNumber:
+		return v.Number
+	case FeaturePerson:
+		return v.Person
+	case FeatureTense:
+		return v.Tense
+	case FeatureSubcat:
+		return v.Subcat
+	case FeatureFlags:
+		return v.Flags
+	}
+	return 0
+}
+
+func NewFeatureIndex(lexicon Lexicon) *FeatureIndex {
+	index := FeatureIndex{}
+	for feature := range index.postings {
+		index.postings[feature] = make(map[byte][]int32)
+	}
+
+	lexicon.Each(WalkOptions{}, func(w *Word) bool {
+		for i := range w.Variants {
+			v := &w.Variants[i]
+			id := int32(len(index.variants))
+			index.variants = append(index.variants, FeatureMatch{w, v})
+			for feature := range index.postings {
+				value := featureValue(v, feature)
+				if feature == FeatureFlags {
+					for bit := byte(1); bit != 0; bit <<= 1 {
+						if value&bit != 0 {
+							index.postings[feature][bit] = append(index.postings[feature][bit], id)
+						}
+					}
+					continue
+				}
+				if value != 0 {
+					index.postings[feature][value] = append(index.postings[feature][value], id)
+				}
+			}
+		}
+		return true
+	})
+	return &index
+}
+
+// posting lists of the constraints of query, shortest first, nil when
+// query has no constraint
+func (index *FeatureIndex) lists(query *WordVariant) (lists [][]int32) {
+	for feature := range index.postings {
+		value := featureValue(query, feature)
+		if feature == FeatureFlags {
+			for bit := byte(1); bit != 0; bit <<= 1 {
+				if value&bit != 0 {
+					lists = append(lists, index.postings[feature][bit])
+				}
+			}
+			continue
+		}
+		if value != 0 {
+			lists = append(lists, index.postings[feature][value])
+		}
+	}
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	return
+}
+
+func intersectPostings(a []int32, b []int32) []int32 {
+	var ids []int32
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			ids = append(ids, a[i])
+			i++
+			j++
+		}
+	}
+	return ids
+}
+
+// calls fn on the variants matching query in rune order of their word
+func (index *FeatureIndex) each(query *WordVariant, fn func(id int32)) {
+	lists := index.lists(query)
+	if len(lists) == 0 {
+		for id := range index.variants {
+			if query.Matches(index.variants[id].Variant) {
+				fn(int32(id))
+			}
+		}
+		return
+	}
+
+	ids := lists[0]
+	for _, list := range lists[1:] {
+		if len(ids) == 0 {
+			return
+		}
+		ids = intersectPostings(ids, list)
+	}
+	for _, id := range ids {
+		// the lemma is not indexed
+		if len(query.Lemma) == 0 || query.Lemma == index.variants[id].Variant.Lemma {
+			fn(id)
+		}
+	}
+}
+
+func (index *FeatureIndex) Variants(query *WordVariant) []FeatureMatch {
+	var matches []FeatureMatch
+
+	index.each(query, func(id int32) {
+		matches = append(matches, index.variants[id])
+	})
+	return matches
+}
+
+// words with a variant matching query in rune order
+func (index *FeatureIndex) Words(query *WordVariant) []*Word {
+	var words []*Word
+
+	index.each(query, func(id int32) {
+		w := index.variants[id].Word
+		if len(words) == 0 || words[len(words)-1] != w {
+			words = append(words, w)
+		}
+	})
+	return words
+}
+
+// number of words and of variants matching query
+func (index *FeatureIndex) Count(query *WordVariant) (words int, variants int) {
+	var last *Word
+	index.each(query, func(id int32) {
+		variants++
+		if w := index.variants[id].Word; w != last {
+			words++
+			last = w
+		}
+	})
+	return
+}
+
+// number of variants matching query by value of feature, the verbs by
+// tense are CountBy(&WordVariant{Tag: VERB}, FeatureTense), flags are
+// counted by bit
+func (index *FeatureIndex) CountBy(query *WordVariant, feature int) map[byte]int {
+	counts := make(map[byte]int)
+	index.each(query, func(id int32) {
+		value := featureValue(index.variants[id].Variant, feature)
+		if feature != FeatureFlags {
+			counts[value]++
+			return
+		}
+		for bit := byte(1); bit != 0; bit <<= 1 {
+			if value&bit != 0 {
+				counts[bit]++
+			}
+		}
+	})
+	return counts
+}
+
+func (index *FeatureIndex) NumVariants() int {
+	return len(index.variants)
+}
+
+func setFeatureValue(v *WordVariant, feature int, value byte) {
+	switch feature {
+	case FeatureTag:
+		v.Tag = value
+	case FeatureLanguage:
+		v.Language = value
+	case FeatureGender:
+		v.Gender = value
+	case FeatureNumber:
+		v.Number = value
+	case FeaturePerson:
+		v.Person = value
+	case FeatureTense:
+		v.Tense = value
+	case FeatureSubcat:
+		v.Subcat = value
+	case FeatureFlags:
+		v.Flags |= value
+	}
+}
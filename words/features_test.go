@@ -0,0 +1,104 @@
+package words
+
+import (
+	"testing"
+)
+
+func GetFeatureDictionary() *Dictionary {
+	dict := GetLemmaDictionary()
+	verbs := []struct {
+		form   string
+		person byte
+		number byte
+		tense  byte
+	}{
+		{"aime", 1, SINGULAR, IND},
+		{"aime", 3, SINGULAR, IND},
+		{"aime", 1, SINGULAR, SUBJ},
+		{"aime", 3, SINGULAR, SUBJ},
+		{"aiment", 3, PLURAL, IND},
+		{"aiment", 3, PLURAL, SUBJ},
+		{"aimions", 1, PLURAL, SUBJ},
+		{"finissent", 3, PLURAL, SUBJ},
+	}
+	for _, v := range verbs {
+		word := Word{}
+		lemma := "aimer"
+		if v.form == "finissent" {
+			lemma = "finir"
+		}
+		word.Variants = append(word.Variants, WordVariant{Tag: VERB, Language: FRENCH, Person: v.person, Number: v.number, Tense: v.tense, Lemma: lemma})
+		dict.AddWord(v.form, &word)
+	}
+	adj := Word{}
+	adj.Variants = append(adj.Variants, WordVariant{Tag: ADJ, Language: FRENCH, Gender: FEMALE, Number: PLURAL, Flags: PROPER, Lemma: "belle"})
+	dict.AddWord("belles", &adj)
+	return dict
+}
+
+func FeatureForms(words []*Word) (forms []string) {
+	for _, w := range words {
+		forms = append(forms, w.String())
+	}
+	return
+}
+
+func TestFeatureIndex(t *testing.T) {
+	dict := GetFeatureDictionary()
+	index := NewFeatureIndex(dict)
+
+	if index.NumVariants() != 13 {
+		t.Errorf("bad variant count %d", index.NumVariants())
+	}
+
+	expected := []struct {
+		query WordVariant
+		forms []string
+	}{
+		{WordVariant{Tag: VERB, Person: 3, Number: PLURAL, Tense: SUBJ}, []string{"aiment", "finissent"}},
+		{WordVariant{Tag: VERB, Person: 1, Number: PLURAL}, []string{"aimions"}},
+		{WordVariant{Gender: FEMALE, Number: PLURAL}, []string{"belles", "chiennes"}},
+		{WordVariant{Tag: ADJ, Flags: PROPER}, []string{"belles"}},
+		{WordVariant{Tag: VERB, Tense: SUBJ, Lemma: "finir"}, []string{"finissent"}},
+		{WordVariant{Tag: NOUN, Tense: SUBJ}, nil},
+		{WordVariant{Lemma: "chien"}, []string{"chien", "chienne", "chiennes", "chiens"}},
+	}
+	for _, e := range expected {
+		forms := FeatureForms(index.Words(&e.query))
+		if len(forms) != len(e.forms) {
+			t.Errorf("%+v gives %v not %v", e.query, forms, e.forms)
+			continue
+		}
+		for i := range forms {
+			if forms[i] != e.forms[i] {
+				t.Errorf("%+v gives %v not %v", e.query, forms, e.forms)
+				break
+			}
+		}
+	}
+
+	// person and tense must hold on the same variant
+	matches := index.Variants(&WordVariant{Tag: VERB, Person: 1, Tense: IND})
+	if len(matches) != 1 || matches[0].Word.String() != "aime" || matches[0].Variant.Tense != IND {
+		t.Errorf("bad variant matches %v", matches)
+	}
+
+	words, variants := index.Count(&WordVariant{Tag: VERB})
+	if words != 4 || variants != 8 {
+		t.Errorf("bad verb counts %d %d", words, variants)
+	}
+	tenses := index.CountBy(&WordVariant{Tag: VERB}, FeatureTense)
+	if len(tenses) != 2 || tenses[IND] != 3 || tenses[SUBJ] != 5 {
+		t.Errorf("bad tense counts %v", tenses)
+	}
+	flags := index.CountBy(&WordVariant{}, FeatureFlags)
+	if len(flags) != 1 || flags[PROPER] != 1 {
+		t.Errorf("bad flag counts %v", flags)
+	}
+
+	compact := NewFeatureIndex(GetCompactDictionary(t, dict))
+	words, variants = compact.Count(&WordVariant{Tag: VERB, Number: PLURAL})
+	if words != 3 || variants != 4 {
+		t.Errorf("bad compact counts %d %d", words, variants)
+	}
+}
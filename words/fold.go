@@ -0,0 +1,130 @@
+package words
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var foldTable = map[rune]string{
+	'à': "a", 'â': "a", 'ä': "a", 'á': "a", 'ã': "a", 'å': "a",
+	'ç': "c",
+	'é': "e", 'è': "e", 'ê': "e", 'ë': "e",
+	'î': "i", 'ï': "i", 'í': "i", 'ì': "i",
+	'ô': "o", 'ö': "o", 'ó': "o", 'ò': "o", 'õ': "o",
+	'ù': "u", 'û': "u", 'ü': "u", 'ú': "u",
+	'ÿ': "y", 'ý': "y",
+	'ñ': "n",
+	'œ': "oe", 'æ': "ae",
+}
+
+func foldRune(r rune) string {
+	r = unicode.ToLower(r)
+	if folded, ok := foldTable[r]; ok {
+		return folded
+	}
+	return string(r)
+}
+
+// lower case s without diacritics, "Élève" and "ELEVE" fold to "eleve"
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		b.WriteString(foldRune(r))
+	}
+	return b.String()
+}
+
+// only words changed by Fold are indexed, the others are found
+// with FindWord on the folded key
+func (dict *Dictionary) addFolded(letters string, word *Word) {
+	key := Fold(letters)
+	if key == letters {
+		return
+	}
+	if dict.folded == nil {
+		dict.folded = make(map[string][]*Word)
+	}
+	for _, w := range dict.folded[key] {
+		if w == word {
+			return
+		}
+	}
+	dict.folded[key] = append(dict.folded[key], word)
+}
+
+// every word whose folded form matches the folded form of s
+func (dict *Dictionary) FindFolded(s string) []*Word {
+	var words []*Word
+
+	key := Fold(s)
+	word, _ := dict.FindWord(key)
+	if word != nil {
+		words = append(words, word)
+	}
+	return append(words, dict.folded[key]...)
+}
+
+// the words of the automaton below node whose folded form is key,
+// without folded index the edges are followed while their folded rune
+// goes on with key
+func (dict *CompactDictionary) findFolded(node uint32, index uint32, key string, prefix []byte, fn func(*Word)) {
+	if len(key) == 0 {
+		if dict.final(node) {
+			fn(dict.word(index, string(prefix)))
+		}
+		return
+	}
+	if dict.final(node) {
+		index++
+	}
+	start, count := dict.nodeEdges(node)
+	for e := start; e < start+count; e++ {
+		r, target, skip := dict.edge(e)
+		if folded := foldRune(r); strings.HasPrefix(key, folded) {
+			dict.findFolded(target, index+skip, key[len(folded):], utf8.AppendRune(prefix, r), fn)
+		}
+	}
+}
+
+// every word whose folded form matches the folded form of s, the word
+// spelled as the folded form first as for Dictionary
+func (dict *CompactDictionary) FindFolded(s string) []*Word {
+	var words []*Word
+
+	key := Fold(s)
+	if len(key) == 0 {
+		return nil
+	}
+	word, _ := dict.FindWord(key)
+	if word != nil {
+		words = append(words, word)
+	}
+	dict.findFolded(dict.root, 0, key, nil, func(w *Word) {
+		if w.String() != key {
+			words = append(words, w)
+		}
+	})
+	return words
+}
+
+// most frequent in freq first, then closest to s, then by form, freq
+// may be nil
+func SortFolded(s string, words []*Word, freq *Frequencies) {
+	lower := strings.ToLower(s)
+	sort.SliceStable(words, func(i, j int) bool {
+		fi := freq.Form(words[i].String())
+		fj := freq.Form(words[j].String())
+		if fi != fj {
+			return fi > fj
+		}
+		di := EditDistance(lower, words[i].String())
+		dj := EditDistance(lower, words[j].String())
+		if di != dj {
+			return di < dj
+		}
+		return words[i].String() < words[j].String()
+	})
+}
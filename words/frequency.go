@@ -0,0 +1,223 @@
+package words
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// corpus counts by form and by lemma, stored next to lm.bin in a
+// .freq file with the lm.bin conventions: magic, version, crc32 trailer
+type Frequencies struct {
+	Forms  map[string]int64
+	Lemmas map[string]int64
+	Total  int64 // occurrences counted
+}
+
+const FrequencyVersion = 1
+
+var FrequencyMagic = [4]byte{'B', 'B', 'F', 'Q'}
+
+var ErrFrequencyCorrupt = errors.New("corrupt frequency file")
+
+func NewFrequencies() *Frequencies {
+	f := Frequencies{}
+	f.Forms = make(map[string]int64)
+	f.Lemmas = make(map[string]int64)
+	return &f
+}
+
+// lm.bin -> lm.freq
+func FrequencyPath(lmpath string) string {
+	return strings.TrimSuffix(lmpath, filepath.Ext(lmpath)) + ".freq"
+}
+
+// adds form counts, the count of a form known by dict is split
+// between its lemmas, the first lemmas get the remainder, so lemma
+// counts add up to the counted occurrences
+func (f *Frequencies) AddCounts(counts map[string]int, dict *Dictionary) {
+	for form, count := range counts {
+		f.Forms[form] += int64(count)
+		f.Total += int64(count)
+		if dict == nil {
+			continue
+		}
+		lemmas := dict.Lemmatize(form)
+		for i, lemma := range lemmas {
+			share := int64(count / len(lemmas))
+			if i < count%len(lemmas) {
+				share++
+			}
+			f.Lemmas[lemma] += share
+		}
+	}
+}
+
+// adds the counts of every .json file written by lm in dir
+func (f *Frequencies) ReadCounts(dir string, dict *Dictionary) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		bytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		counts := make(map[string]int)
+		err = json.Unmarshal(bytes, &counts)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		f.AddCounts(counts, dict)
+	}
+	return nil
+}
+
+func (f *Frequencies) Form(form string) int64 {
+	if f == nil {
+		return 0
+	}
+	return f.Forms[form]
+}
+
+func (f *Frequencies) Lemma(lemma string) int64 {
+	if f == nil {
+		return 0
+	}
+	return f.Lemmas[lemma]
+}
+
+// variants of word, most frequent lemma first
+func (f *Frequencies) RankVariants(word *Word) []WordVariant {
+	variants := append([]WordVariant{}, word.Variants...)
+	sort.SliceStable(variants, func(i, j int) bool {
+		return f.Lemma(variants[i].Lemma) > f.Lemma(variants[j].Lemma)
+	})
+	return variants
+}
+
+// most frequent first, then by form
+func (f *Frequencies) SortWords(words []*Word) {
+	sort.SliceStable(words, func(i, j int) bool {
+		fi := f.Form(words[i].String())
+		fj := f.Form(words[j].String())
+		if fi != fj {
+			return fi > fj
+		}
+		return words[i].String() < words[j].String()
+	})
+}
+
+func writeFrequencyTable(w io.Writer, table map[string]int64) {
+	keys := make([]string, 0, len(table))
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	binary.Write(w, binary.LittleEndian, int64(len(keys)))
+	for _, key := range keys {
+		writeBinaryString(w, key)
+		binary.Write(w, binary.LittleEndian, table[key])
+	}
+}
+
+func readFrequencyTable(r io.Reader, table map[string]int64) error {
+	var n int64
+	err := binary.Read(r, binary.LittleEndian, &n)
+	if err != nil {
+		return err
+	}
+	for i := int64(0); i < n; i++ {
+		key, err := readBinaryString(r)
+		if err != nil {
+			return err
+		}
+		var count int64
+		err = binary.Read(r, binary.LittleEndian, &count)
+		if err != nil {
+			return err
+		}
+		table[key] = count
+	}
+	return nil
+}
+
+func (f *Frequencies) Write(path string) error {
+	return writeFileAtomic(path, f.encode)
+}
+
+func (f *Frequencies) encode(bw io.Writer) error {
+	crc := crc32.NewIEEE()
+	w := io.MultiWriter(bw, crc)
+
+	binary.Write(w, binary.LittleEndian, FrequencyMagic)
+	binary.Write(w, binary.LittleEndian, uint32(FrequencyVersion))
+	binary.Write(w, binary.LittleEndian, f.Total)
+	writeFrequencyTable(w, f.Forms)
+	writeFrequencyTable(w, f.Lemmas)
+	return binary.Write(bw, binary.LittleEndian, crc.Sum32())
+}
+
+func ReadFrequencies(path string) (*Frequencies, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	br := bufio.NewReader(file)
+	crc := crc32.NewIEEE()
+	r := io.TeeReader(br, crc)
+
+	f := NewFrequencies()
+	err = f.read(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", path, ErrFrequencyCorrupt, err)
+	}
+
+	var sum uint32
+	err = binary.Read(br, binary.LittleEndian, &sum)
+	if err != nil || sum != crc.Sum32() {
+		return nil, fmt.Errorf("%s: %w: checksum mismatch", path, ErrFrequencyCorrupt)
+	}
+	return f, nil
+}
+
+func (f *Frequencies) read(r io.Reader) error {
+	var magic [4]byte
+	var version uint32
+	err := binary.Read(r, binary.LittleEndian, &magic)
+	if err != nil {
+		return err
+	}
+	if magic != FrequencyMagic {
+		return fmt.Errorf("bad magic")
+	}
+	err = binary.Read(r, binary.LittleEndian, &version)
+	if err != nil {
+		return err
+	}
+	if version != FrequencyVersion {
+		return fmt.Errorf("unsupported version %d", version)
+	}
+	err = binary.Read(r, binary.LittleEndian, &f.Total)
+	if err != nil {
+		return err
+	}
+	err = readFrequencyTable(r, f.Forms)
+	if err != nil {
+		return err
+	}
+	return readFrequencyTable(r, f.Lemmas)
+}
@@ -0,0 +1,130 @@
+package words
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func GetFrequencies() *Frequencies {
+	dict := GetLemmaDictionary()
+	freq := NewFrequencies()
+	freq.AddCounts(map[string]int{"chien": 10, "chiens": 4, "chienne": 2, "inconnu": 1}, dict)
+	return freq
+}
+
+func TestFrequencyCounts(t *testing.T) {
+	freq := GetFrequencies()
+	if freq.Total != 17 {
+		t.Errorf("bad total %d", freq.Total)
+	}
+	if freq.Form("chiens") != 4 || freq.Form("chiennes") != 0 {
+		t.Errorf("bad form counts %v", freq.Forms)
+	}
+	if freq.Lemma("chien") != 16 {
+		t.Errorf("bad lemma count %d", freq.Lemma("chien"))
+	}
+
+	// an ambiguous form shares its count between its lemmas
+	dict := GetLemmaDictionary()
+	porte := Word{}
+	porte.Variants = append(porte.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Lemma: "porte"},
+		WordVariant{Tag: VERB, Language: FRENCH, Lemma: "porter"})
+	dict.AddWord("porte", &porte)
+	freq = NewFrequencies()
+	freq.AddCounts(map[string]int{"porte": 5}, dict)
+	if freq.Lemma("porte") != 3 || freq.Lemma("porter") != 2 || freq.Total != 5 {
+		t.Errorf("porte count not split %v", freq.Lemmas)
+	}
+
+	var nilfreq *Frequencies
+	if nilfreq.Form("chien") != 0 || nilfreq.Lemma("chien") != 0 {
+		t.Errorf("nil frequencies should count nothing")
+	}
+}
+
+func TestFrequencyReadCounts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lmoutput")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"chien": 3, "chiens": 1}`), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.json"), []byte(`{"chien": 2}`), 0644)
+
+	freq := NewFrequencies()
+	err = freq.ReadCounts(dir, GetLemmaDictionary())
+	if err != nil {
+		t.Fatalf("cannot read counts %s", err)
+	}
+	if freq.Form("chien") != 5 || freq.Lemma("chien") != 6 || freq.Total != 6 {
+		t.Errorf("bad counts %v %v", freq.Forms, freq.Lemmas)
+	}
+}
+
+func TestFrequencyBinary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.freq")
+	freq := GetFrequencies()
+	err := freq.Write(path)
+	if err != nil {
+		t.Fatalf("cannot write frequencies %s", err)
+	}
+
+	read, err := ReadFrequencies(path)
+	if err != nil {
+		t.Fatalf("cannot read frequencies %s", err)
+	}
+	if read.Total != freq.Total || len(read.Forms) != len(freq.Forms) || read.Lemma("chien") != 16 || read.Form("chien") != 10 {
+		t.Errorf("frequencies not read back %v %v", read.Forms, read.Lemmas)
+	}
+
+	bytes, _ := ioutil.ReadFile(path)
+	bytes[len(bytes)/2] ^= 0xff
+	ioutil.WriteFile(path, bytes, 0644)
+	_, err = ReadFrequencies(path)
+	if !errors.Is(err, ErrFrequencyCorrupt) {
+		t.Errorf("corruption not detected %v", err)
+	}
+
+	if FrequencyPath("lm/lm.bin") != "lm/lm.freq" {
+		t.Errorf("bad frequency path %s", FrequencyPath("lm/lm.bin"))
+	}
+}
+
+func TestFrequencyRanking(t *testing.T) {
+	dict := Dictionary{}
+	for _, form := range []string{"pain", "bain", "main", "mains"} {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Lemma: form})
+		dict.AddWord(form, &word)
+	}
+
+	suggestions := dict.Suggest("gain", FRENCH, 1, 0)
+	if len(suggestions) != 3 || suggestions[0].Word.String() != "bain" {
+		t.Errorf("suggestions should be sorted by form without frequencies")
+	}
+
+	dict.Frequencies = NewFrequencies()
+	dict.Frequencies.AddCounts(map[string]int{"main": 30, "pain": 10, "mains": 50}, &dict)
+
+	suggestions = dict.Suggest("gain", FRENCH, 1, 0)
+	if len(suggestions) != 3 || suggestions[0].Word.String() != "main" || suggestions[1].Word.String() != "pain" {
+		t.Errorf("suggestions not ranked by frequency")
+	}
+
+	words := dict.AutoComplete("ma", nil)
+	if len(words) != 2 || words[0].String() != "mains" {
+		t.Errorf("autocomplete not ranked by frequency")
+	}
+
+	word := Word{}
+	word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Lemma: "pain"})
+	word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Lemma: "main"})
+	variants := dict.RankVariants(&word)
+	if len(variants) != 2 || variants[0].Lemma != "main" {
+		t.Errorf("variants not ranked by lemma frequency")
+	}
+}
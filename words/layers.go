@@ -0,0 +1,429 @@
+package words
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"unicode/utf8"
+)
+
+// runtime additions and suppressions over a base lexicon
+type UserLayer struct {
+	Name string
+	Path string // file used by Save, empty for memory only layers
+
+	dict       Dictionary
+	suppressed map[string][]WordVariant // no variant suppresses the whole form
+	changes    int                      // AddWord and Suppress calls
+}
+
+// layers are applied in order over the base, a layer suppresses
+// variants of the base and of the layers before it
+type LayeredDictionary struct {
+	Base   Lexicon
+	Layers []*UserLayer
+}
+
+// implemented by lexicons with a spelling suggestion search
+type Suggester interface {
+	Suggest(word string, lang byte, maxdistance int, limit int) []Suggestion
+}
+
+var _ Lexicon = (*LayeredDictionary)(nil)
+
+type userLayerEntry struct {
+	Form     string        `json:"form"`
+	Variants []WordVariant `json:"variants,omitempty"`
+}
+
+type userLayerFile struct {
+	Name       string           `json:"name"`
+	Words      []userLayerEntry `json:"words"`
+	Suppressed []userLayerEntry `json:"suppressed"`
+}
+
+func NewUserLayer(name string, path string) *UserLayer {
+	layer := UserLayer{}
+	layer.Name = name
+	layer.Path = path
+	layer.suppressed = make(map[string][]WordVariant)
+	return &layer
+}
+
+// loads a layer saved with Save, a missing file gives an empty layer
+func ReadUserLayer(path string) (*UserLayer, error) {
+	layer := NewUserLayer("", path)
+
+	bytes, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return layer, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	file := userLayerFile{}
+	err = json.Unmarshal(bytes, &file)
+	if err != nil {
+		return nil, err
+	}
+	layer.Name = file.Name
+	for _, entry := range file.Words {
+		layer.AddWord(entry.Form, entry.Variants...)
+	}
+	for _, entry := range file.Suppressed {
+		layer.Suppress(entry.Form, entry.Variants...)
+	}
+	return layer, nil
+}
+
+func (layer *UserLayer) Save() error {
+	file := userLayerFile{}
+	file.Name = layer.Name
+
+	layer.dict.Each(WalkOptions{}, func(word *Word) bool {
+		file.Words = append(file.Words, userLayerEntry{word.String(), word.Variants})
+		return true
+	})
+	for form, variants := range layer.suppressed {
+		file.Suppressed = append(file.Suppressed, userLayerEntry{form, variants})
+	}
+	sort.Slice(file.Suppressed, func(i, j int) bool { return file.Suppressed[i].Form < file.Suppressed[j].Form })
+
+	bytes, err := json.MarshalIndent(&file, "", " ")
+	if err != nil {
+		return err
+	}
+	// a crash while saving leaves the previous file
+	return writeFileAtomic(layer.Path, func(w io.Writer) error {
+		_, err := w.Write(bytes)
+		return err
+	})
+}
+
+func (layer *UserLayer) AddWord(form string, variants ...WordVariant) {
+	layer.changes++
+	word := Word{}
+	word.AddVariants(variants)
+	layer.dict.AddWord(form, &word)
+}
+
+// hides the variants of lower layers matching one of variants, or
+// the whole form when no variant is given
+func (layer *UserLayer) Suppress(form string, variants ...WordVariant) {
+	layer.changes++
+	current, found := layer.suppressed[form]
+	if found && len(current) == 0 {
+		return
+	}
+	if len(variants) == 0 {
+		layer.suppressed[form] = []WordVariant{}
+		return
+	}
+	layer.suppressed[form] = append(current, variants...)
+}
+
+// copy of the layer sharing nothing with it
+func (layer *UserLayer) Clone() *UserLayer {
+	clone := NewUserLayer(layer.Name, layer.Path)
+
+	layer.dict.Each(WalkOptions{}, func(word *Word) bool {
+		clone.AddWord(word.String(), word.Variants...)
+		return true
+	})
+	for form, variants := range layer.suppressed {
+		clone.suppressed[form] = append([]WordVariant{}, variants...)
+	}
+	return clone
+}
+
+// layer with the changes of layer then of top, so that a lexicon gives
+// the same words with it as with layer and top over it
+func mergeLayers(name string, layer *UserLayer, top *UserLayer) *UserLayer {
+	merged := NewUserLayer(name, "")
+	for _, l := range []*UserLayer{layer, top} {
+		for form, variants := range l.suppressed {
+			merged.Suppress(form, variants...)
+		}
+	}
+	// the suppressions of top hide the words of layer but not its own
+	layer.dict.Each(WalkOptions{}, func(word *Word) bool {
+		variants, _ := top.hide(word.String(), word.Variants)
+		if len(variants) > 0 {
+			merged.AddWord(word.String(), variants...)
+		}
+		return true
+	})
+	top.dict.Each(WalkOptions{}, func(word *Word) bool {
+		merged.AddWord(word.String(), word.Variants...)
+		return true
+	})
+	return merged
+}
+
+// variants of form from lower layers not suppressed by the layer
+func (layer *UserLayer) hide(form string, variants []WordVariant) ([]WordVariant, bool) {
+	suppressed, found := layer.suppressed[form]
+	if !found {
+		return variants, false
+	}
+	var kept []WordVariant
+	for i := range variants {
+		hide := len(suppressed) == 0
+		for j := range suppressed {
+			if suppressed[j].Matches(&variants[i]) {
+				hide = true
+				break
+			}
+		}
+		if !hide {
+			kept = append(kept, variants[i])
+		}
+	}
+	return kept, true
+}
+
+// applies the layer to the variants of form from lower layers
+func (layer *UserLayer) apply(form string, variants []WordVariant) ([]WordVariant, bool, bool) {
+	variants, touched := layer.hide(form, variants)
+
+	word, foundPath := layer.dict.FindWord(form)
+	if word != nil {
+		touched = true
+		merged := Word{}
+		merged.Variants = append(merged.Variants, variants...)
+		merged.AddVariants(word.Variants)
+		variants = merged.Variants
+	}
+	return variants, touched, foundPath
+}
+
+func (ld *LayeredDictionary) AddLayer(layer *UserLayer) {
+	ld.Layers = append(ld.Layers, layer)
+}
+
+// merged view of form, the base word is returned as is when no layer
+// changes it
+func (ld *LayeredDictionary) FindWord(letters string) (*Word, bool) {
+	base, foundPath := ld.Base.FindWord(letters)
+
+	var variants []WordVariant
+	if base != nil {
+		variants = base.Variants
+	}
+	touched := false
+	for _, layer := range ld.Layers {
+		var layerTouched, layerPath bool
+		variants, layerTouched, layerPath = layer.apply(letters, variants)
+		touched = touched || layerTouched
+		foundPath = foundPath || layerPath
+	}
+
+	if !touched {
+		return base, foundPath
+	}
+	if len(variants) == 0 {
+		return nil, foundPath
+	}
+	word := Word{}
+	word.Variants = variants
+	word.form = letters
+	return &word, foundPath
+}
+
+func (ld *LayeredDictionary) FindLonguestWord(letters string) *Word {
+	var word *Word
+	for i := range letters {
+		if i == 0 {
+			continue
+		}
+		w, foundPath := ld.FindWord(letters[:i])
+		if !foundPath {
+			return word
+		}
+		if w != nil {
+			word = w
+		}
+	}
+	w, _ := ld.FindWord(letters)
+	if w != nil {
+		word = w
+	}
+	return word
+}
+
+func (ld *LayeredDictionary) FindFolded(s string) []*Word {
+	var words []*Word
+
+	seen := make(map[string]bool)
+	add := func(candidates []*Word) {
+		for _, candidate := range candidates {
+			form := candidate.String()
+			if seen[form] {
+				continue
+			}
+			seen[form] = true
+			word, _ := ld.FindWord(form)
+			if word != nil {
+				words = append(words, word)
+			}
+		}
+	}
+
+	if folder, ok := ld.Base.(FoldedLexicon); ok {
+		add(folder.FindFolded(s))
+	}
+	for _, layer := range ld.Layers {
+		add(layer.dict.FindFolded(s))
+	}
+	return words
+}
+
+// merged words of the base and every layer in rune order, the forms
+// of the layers, few compared to the base, are merged into the walk
+// of the base
+func (ld *LayeredDictionary) Each(options WalkOptions, fn func(*Word) bool) {
+	var forms []string
+	for _, layer := range ld.Layers {
+		layer.dict.Each(options, func(w *Word) bool {
+			forms = append(forms, w.String())
+			return true
+		})
+	}
+	sort.Strings(forms)
+
+	emit := func(form string) bool {
+		word, _ := ld.FindWord(form)
+		return word == nil || fn(word)
+	}
+	// emits the layer forms before form, a form of the base is emitted
+	// by the walk of the base
+	next := 0
+	pending := func(form string, last bool) bool {
+		for ; next < len(forms) && (last || forms[next] <= form); next++ {
+			duplicate := next > 0 && forms[next] == forms[next-1]
+			if duplicate || (!last && forms[next] == form) {
+				continue
+			}
+			if !emit(forms[next]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	stopped := false
+	ld.Base.Each(options, func(w *Word) bool {
+		form := w.String()
+		stopped = !pending(form, false) || !emit(form)
+		return !stopped
+	})
+	if !stopped {
+		pending("", true)
+	}
+}
+
+func (ld *LayeredDictionary) Walk(wordch chan *Word) {
+	ld.Each(WalkOptions{}, func(w *Word) bool {
+		wordch <- w
+		return true
+	})
+	close(wordch)
+}
+
+func (ld *LayeredDictionary) WalkOfSize(size int, wordch chan *Word) {
+	ld.Each(WalkOptions{Size: size}, func(w *Word) bool {
+		wordch <- w
+		return true
+	})
+	close(wordch)
+}
+
+func (ld *LayeredDictionary) WalkFromPath(word string, wordch chan *Word) {
+	if len(word) > 0 {
+		ld.Each(WalkOptions{Prefix: word}, func(w *Word) bool {
+			wordch <- w
+			return true
+		})
+	}
+	close(wordch)
+}
+
+func (ld *LayeredDictionary) AutoComplete(word string, filter *WordVariant) []*Word {
+	var words []*Word
+
+	if len(word) == 0 {
+		return nil
+	}
+	ld.Each(WalkOptions{Prefix: word}, func(w *Word) bool {
+		if filter == nil || filter.Filter(w) {
+			words = append(words, w)
+		}
+		return true
+	})
+	return words
+}
+
+func (ld *LayeredDictionary) Limits() (int, int) {
+	maxWordLen, maxTokens := ld.Base.Limits()
+	for _, layer := range ld.Layers {
+		if layer.dict.MaxWordLen > maxWordLen {
+			maxWordLen = layer.dict.MaxWordLen
+		}
+		if layer.dict.MaxTokens > maxTokens {
+			maxTokens = layer.dict.MaxTokens
+		}
+	}
+	return maxWordLen, maxTokens
+}
+
+// suggestions of the base and of every layer on the merged view
+func (ld *LayeredDictionary) Suggest(word string, lang byte, maxdistance int, limit int) []Suggestion {
+	var suggestions []Suggestion
+
+	var candidates []Suggestion
+	if suggester, ok := ld.Base.(Suggester); ok {
+		candidates = append(candidates, suggester.Suggest(word, 0, maxdistance, 0)...)
+	}
+	for _, layer := range ld.Layers {
+		candidates = append(candidates, layer.dict.Suggest(word, 0, maxdistance, 0)...)
+	}
+
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		form := candidate.Word.String()
+		if seen[form] {
+			continue
+		}
+		seen[form] = true
+		merged, _ := ld.FindWord(form)
+		if merged != nil && (lang == 0 || merged.Language(lang)) {
+			suggestions = append(suggestions, Suggestion{merged, candidate.Distance})
+		}
+	}
+
+	var freq *Frequencies
+	if dict, ok := ld.Base.(*Dictionary); ok {
+		freq = dict.Frequencies
+	}
+	SortSuggestions(suggestions, freq)
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// Dictionary.FindAlternatives on the merged view
+func (ld *LayeredDictionary) FindAlternatives(word string, lang byte, maxerror int) []*Word {
+	var words []*Word
+
+	ld.Each(WalkOptions{Size: utf8.RuneCountInString(word)}, func(w *Word) bool {
+		if CountError(word, w.String()) <= maxerror && w.Language(lang) {
+			words = append(words, w)
+		}
+		return true
+	})
+	return words
+}
@@ -0,0 +1,191 @@
+package words
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func GetLayeredDictionary() (*LayeredDictionary, *UserLayer) {
+	base := GetLemmaDictionary()
+	base.AddBuiltin()
+	base.AddWordWithTag(" ", SPACE)
+	verb := Word{}
+	verb.Variants = append(verb.Variants, WordVariant{Tag: VERB, Language: FRENCH, Lemma: "chienner"},
+		WordVariant{Tag: NOUN, Language: FRENCH, Lemma: "chienne", Gender: FEMALE, Number: SINGULAR})
+	base.AddWord("chienne", &verb)
+
+	layer := NewUserLayer("produits", "")
+	layer.AddWord("babble", WordVariant{Tag: NOUN, Language: FRENCH, Gender: MALE, Number: SINGULAR, Lemma: "babble"})
+	layer.Suppress("chiens")
+	layer.Suppress("chienne", WordVariant{Tag: VERB})
+
+	ld := LayeredDictionary{}
+	ld.Base = base
+	ld.AddLayer(layer)
+	return &ld, layer
+}
+
+func TestLayeredFindWord(t *testing.T) {
+	ld, _ := GetLayeredDictionary()
+
+	word, _ := ld.FindWord("babble")
+	if word == nil || word.String() != "babble" || !word.Tagged(NOUN) {
+		t.Errorf("babble not added")
+	}
+	word, found := ld.FindWord("chiens")
+	if word != nil || !found {
+		t.Errorf("chiens not suppressed")
+	}
+	word, _ = ld.FindWord("chienne")
+	if word == nil || word.Tagged(VERB) || !word.Tagged(NOUN) {
+		t.Errorf("chienne verb not suppressed")
+	}
+	word, _ = ld.FindWord("chien")
+	base, _ := ld.Base.FindWord("chien")
+	if word != base {
+		t.Errorf("untouched word copied")
+	}
+
+	top := NewUserLayer("top", "")
+	top.AddWord("chiens", WordVariant{Tag: NOUN, Language: FRENCH, Lemma: "chien", Number: PLURAL})
+	ld.AddLayer(top)
+	word, _ = ld.FindWord("chiens")
+	if word == nil {
+		t.Errorf("chiens not restored by upper layer")
+	}
+}
+
+func TestLayeredTokenize(t *testing.T) {
+	ld, _ := GetLayeredDictionary()
+	context := NewTestContext(ld)
+
+	text := "babble chiens"
+	tokens := Tokenize(text, context, false)
+	if len(tokens) != 3 {
+		t.Fatalf("bad token count %d", len(tokens))
+	}
+	if tokens[0].Word == nil || tokens[2].Word != nil {
+		t.Errorf("tokenizer does not see layers")
+	}
+
+	words := ld.AutoComplete("chien", nil)
+	if len(words) != 3 {
+		t.Errorf("chien completions %d", len(words))
+	}
+}
+
+func TestLayeredSuggest(t *testing.T) {
+	ld, _ := GetLayeredDictionary()
+
+	suggestions := ld.Suggest("bable", FRENCH, 1, 0)
+	if len(suggestions) != 1 || suggestions[0].Word.String() != "babble" {
+		t.Errorf("babble not suggested")
+	}
+	suggestions = ld.Suggest("chient", FRENCH, 1, 0)
+	for _, suggestion := range suggestions {
+		if suggestion.Word.String() == "chiens" {
+			t.Errorf("suppressed chiens suggested")
+		}
+	}
+}
+
+func TestLayeredSuggestCompact(t *testing.T) {
+	ld, layer := GetLayeredDictionary()
+	compact := LayeredDictionary{}
+	compact.Base = GetCompactDictionary(t, ld.Base.(*Dictionary))
+	compact.AddLayer(layer)
+
+	for _, word := range []string{"chein", "bable"} {
+		expected := SuggestionForms(ld.Suggest(word, FRENCH, 1, 0))
+		forms := SuggestionForms(compact.Suggest(word, FRENCH, 1, 0))
+		if len(forms) == 0 || forms != expected {
+			t.Errorf("%s suggests %q over a compact base, expected %q", word, forms, expected)
+		}
+	}
+}
+
+func SuggestionForms(suggestions []Suggestion) string {
+	var forms []string
+	for _, suggestion := range suggestions {
+		forms = append(forms, suggestion.Word.String())
+	}
+	return strings.Join(forms, " ")
+}
+
+func TestUserLayerSave(t *testing.T) {
+	_, layer := GetLayeredDictionary()
+	layer.Path = filepath.Join(t.TempDir(), "produits.json")
+	err := layer.Save()
+	if err != nil {
+		t.Fatalf("cannot save layer %s", err)
+	}
+
+	read, err := ReadUserLayer(layer.Path)
+	if err != nil {
+		t.Fatalf("cannot read layer %s", err)
+	}
+	if read.Name != "produits" {
+		t.Errorf("bad layer name %s", read.Name)
+	}
+
+	ld := LayeredDictionary{}
+	ld.Base = GetLemmaDictionary()
+	ld.AddLayer(read)
+	word, _ := ld.FindWord("babble")
+	if word == nil || word.Variants[0].Lemma != "babble" || word.Variants[0].Gender != MALE {
+		t.Errorf("babble not read back")
+	}
+	word, _ = ld.FindWord("chiens")
+	if word != nil {
+		t.Errorf("chiens suppression not read back")
+	}
+
+	// saved again through a renamed temporary file
+	layer.AddWord("chat", WordVariant{Tag: NOUN, Language: FRENCH})
+	err = layer.Save()
+	if err != nil {
+		t.Fatalf("cannot save layer again %s", err)
+	}
+	files, _ := ioutil.ReadDir(filepath.Dir(layer.Path))
+	if len(files) != 1 || files[0].Name() != "produits.json" {
+		t.Errorf("temporary files left %v", files)
+	}
+	read, err = ReadUserLayer(layer.Path)
+	if err != nil {
+		t.Fatalf("cannot read layer again %s", err)
+	}
+	ld = LayeredDictionary{}
+	ld.Base = GetLemmaDictionary()
+	ld.AddLayer(read)
+	if word, _ = ld.FindWord("chat"); word == nil {
+		t.Errorf("second save not read back")
+	}
+}
+
+func TestLayeredEach(t *testing.T) {
+	ld, _ := GetLayeredDictionary()
+	top := NewUserLayer("top", "")
+	top.AddWord("chat", WordVariant{Tag: NOUN, Language: FRENCH})
+	top.AddWord("babble", WordVariant{Tag: VERB, Language: FRENCH})
+	ld.AddLayer(top)
+
+	words := strings.Join(CollectEach(ld, WalkOptions{Prefix: "ch"}), " ")
+	if words != "chat chien chienne chiennes" {
+		t.Errorf("bad layered walk '%s'", words)
+	}
+	words = strings.Join(CollectEach(ld, WalkOptions{Prefix: "b"}), " ")
+	if words != "babble" {
+		t.Errorf("bad layered walk '%s'", words)
+	}
+
+	var first []string
+	ld.Each(WalkOptions{Prefix: "ch"}, func(w *Word) bool {
+		first = append(first, w.String())
+		return false
+	})
+	if len(first) != 1 || first[0] != "chat" {
+		t.Errorf("layered walk not stopped %v", first)
+	}
+}
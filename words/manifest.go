@@ -0,0 +1,242 @@
+package words
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// lists the dictionary sources of a lexicon, relative paths are
+// relative to the manifest file
+//
+//	{
+//	  "binary": "lm/lm.bin",
+//	  "frequencies": "lm/lm.freq",
+//	  "policy": "skip",
+//	  "sources": [
+//	    {"path": "lmdata/dela-fr-public-u8.dic.xml", "language": "fr", "priority": 10},
+//	    {"path": "lmdata/domain.dic", "format": "delaf", "language": "fr"}
+//	  ]
+//	}
+type Manifest struct {
+	Binary      string           `json:"binary,omitempty"`      // compiled lm.bin
+	Compact     string           `json:"compact,omitempty"`     // compact dictionary, used before binary
+	Frequencies string           `json:"frequencies,omitempty"` // corpus frequencies of the dictionary
+	Policy      string           `json:"policy,omitempty"`      // fail, skip or map unknown attributes
+	Sources     []ManifestSource `json:"sources"`
+
+	dir string
+}
+
+type ManifestSource struct {
+	Path     string `json:"path"`
+	Format   string `json:"format,omitempty"`   // xml or delaf, guessed from path when empty
+	Language string `json:"language,omitempty"` // fr, en or empty for any language
+	Priority int    `json:"priority,omitempty"` // forms of a source hide those of lower priorities
+}
+
+var manifestLanguages = map[string]byte{
+	"":        0,
+	"fr":      FRENCH,
+	"french":  FRENCH,
+	"en":      ENGLISH,
+	"english": ENGLISH,
+}
+
+var manifestPolicies = map[string]byte{
+	"":     FAILUNKNOWN,
+	"fail": FAILUNKNOWN,
+	"skip": SKIPUNKNOWN,
+	"map":  MAPUNKNOWN,
+}
+
+// the lexicon formerly hard coded in ReadXML
+func DefaultManifest() *Manifest {
+	m := Manifest{}
+	m.Binary = "lm/lm.bin"
+	m.Sources = []ManifestSource{
+		{"lmdata/dela-fr-public-u8.dic.xml", FormatDelaXML, "fr", 0},
+		{"lmdata/dela-addon-fr-u8.dic.xml", FormatDelaXML, "fr", 0},
+		{"lmdata/dela-abbr-fr-u8.dic.xml", FormatDelaXML, "fr", 0},
+		{"lmdata/dela-en-public-u8.dic.xml", FormatDelaXML, "en", 0},
+		{"lmdata/dela-proper-u8.dic.xml", FormatDelaXML, "", 0},
+		{"lmdata/dela-acron-u8.dic.xml", FormatDelaXML, "", 0},
+		{"lmdata/dela-punc-u8.dic.xml", FormatDelaXML, "", 0},
+	}
+	return &m
+}
+
+func ReadManifest(path string) (*Manifest, error) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := Manifest{}
+	err = json.Unmarshal(bytes, &m)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	m.dir = filepath.Dir(path)
+
+	err = m.Validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &m, nil
+}
+
+func (m *Manifest) Validate() error {
+	_, err := ParsePolicy(m.Policy)
+	if err != nil {
+		return err
+	}
+	for _, source := range m.Sources {
+		if len(source.Path) == 0 {
+			return fmt.Errorf("source without path")
+		}
+		_, err = source.Lang()
+		if err != nil {
+			return err
+		}
+		format := source.GetFormat()
+		if format != FormatDelaXML && format != FormatDelaf {
+			return fmt.Errorf("%s: unknown format '%s'", source.Path, format)
+		}
+	}
+	return nil
+}
+
+func ParsePolicy(name string) (byte, error) {
+	policy, ok := manifestPolicies[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown policy '%s'", name)
+	}
+	return policy, nil
+}
+
+// language of a name fr, french, en or english, 0 for ""
+func ParseLanguage(name string) (byte, error) {
+	lang, ok := manifestLanguages[strings.ToLower(name)]
+	if !ok {
+		return 0, fmt.Errorf("unknown language '%s'", name)
+	}
+	return lang, nil
+}
+
+func (source *ManifestSource) Lang() (byte, error) {
+	lang, ok := manifestLanguages[strings.ToLower(source.Language)]
+	if !ok {
+		return 0, fmt.Errorf("%s: unknown language '%s'", source.Path, source.Language)
+	}
+	return lang, nil
+}
+
+func (source *ManifestSource) GetFormat() string {
+	if len(source.Format) > 0 {
+		return source.Format
+	}
+	if strings.HasSuffix(source.Path, ".xml") {
+		return FormatDelaXML
+	}
+	return FormatDelaf
+}
+
+// resolves path relative to the manifest file
+func (m *Manifest) Resolve(path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) || len(m.dir) == 0 {
+		return path
+	}
+	return filepath.Join(m.dir, path)
+}
+
+// sources by decreasing priority, in file order for equal priorities
+func (m *Manifest) SortedSources() []ManifestSource {
+	sources := append([]ManifestSource{}, m.Sources...)
+	sort.SliceStable(sources, func(i, j int) bool {
+		return sources[i].Priority > sources[j].Priority
+	})
+	return sources
+}
+
+// reads every source of the manifest and adds the builtin words
+func (dict *Dictionary) ReadManifest(m *Manifest) (summary ReadSummary, err error) {
+	policy, err := ParsePolicy(m.Policy)
+	if err != nil {
+		return
+	}
+	return dict.readManifest(m, policy)
+}
+
+// a form read from a source is not added from the sources of lower
+// priorities, sources of equal priority merge their variants
+func (dict *Dictionary) readManifest(m *Manifest, policy byte) (summary ReadSummary, err error) {
+	sources := m.SortedSources()
+	var added map[string]bool // forms added at the current priority
+	for i, source := range sources {
+		var lang byte
+		lang, err = source.Lang()
+		if err != nil {
+			return
+		}
+		if i > 0 && source.Priority != sources[i-1].Priority {
+			added = make(map[string]bool)
+		}
+		hidden := 0
+		addWord := func(form string, word *Word) error {
+			if added != nil && !added[form] {
+				if found, _ := dict.FindWord(form); found != nil {
+					hidden++
+					return nil
+				}
+				added[form] = true
+			}
+			dict.AddWord(form, word)
+			return nil
+		}
+		var read ReadSummary
+		read, err = dict.readFormat(m.Resolve(source.Path), source.GetFormat(), lang, policy, addWord)
+		read.Forms -= hidden
+		read.Hidden = hidden
+		summary.Add(&read)
+		if err != nil {
+			return
+		}
+	}
+
+	word := new(Word)
+	dict.AddWord("...", word)
+
+	dict.AddBuiltin()
+
+	return
+}
+
+// loads the compact dictionary, the binary or the sources of the
+// manifest, whichever comes first
+func (m *Manifest) Lexicon() (lexicon Lexicon, dict *Dictionary, err error) {
+	if len(m.Compact) > 0 {
+		lexicon, err = ReadCompact(m.Resolve(m.Compact))
+		return
+	}
+
+	dict = new(Dictionary)
+	if len(m.Binary) > 0 {
+		err = dict.ReadBinary(m.Resolve(m.Binary))
+	} else {
+		_, err = dict.ReadManifest(m)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(m.Frequencies) > 0 {
+		dict.Frequencies, err = ReadFrequencies(m.Resolve(m.Frequencies))
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return dict, dict, nil
+}
@@ -0,0 +1,108 @@
+package words
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const testManifest = `{
+  "policy": "skip",
+  "sources": [
+    {"path": "low.dic", "language": "fr"},
+    {"path": "high.dic", "format": "delaf", "language": "en", "priority": 10},
+    {"path": "addon.dic", "language": "en", "priority": 10}
+  ]
+}`
+
+func TestManifest(t *testing.T) {
+	dir := t.TempDir()
+	ioutil.WriteFile(filepath.Join(dir, "manifest.json"), []byte(testManifest), 0666)
+	ioutil.WriteFile(filepath.Join(dir, "low.dic"), []byte("test,test.N:ms\nodd,odd.ODD\nchat,chat.N:ms\n"), 0666)
+	ioutil.WriteFile(filepath.Join(dir, "high.dic"), []byte("test,test.N:s\n"), 0666)
+	ioutil.WriteFile(filepath.Join(dir, "addon.dic"), []byte("test,test.V:W\n"), 0666)
+
+	m, err := ReadManifest(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("cannot read manifest %s", err)
+	}
+
+	dict := Dictionary{}
+	summary, err := dict.ReadManifest(m)
+	if err != nil {
+		t.Fatalf("cannot read manifest sources %s", err)
+	}
+	if summary.Skipped != 1 || summary.Forms != 3 || summary.Hidden != 1 {
+		t.Errorf("bad summary %s", summary.String())
+	}
+
+	// sources of equal priority merge, lower priorities do not add
+	// variants to their forms
+	word, _ := dict.FindWord("test")
+	if word == nil || len(word.Variants) != 2 || !word.Language(ENGLISH) || word.Language(FRENCH) {
+		t.Errorf("test variants not hidden by priority %v", word)
+	}
+	word, _ = dict.FindWord("chat")
+	if word == nil || !word.Language(FRENCH) {
+		t.Errorf("chat of the low priority source not added")
+	}
+	if len(dict.Sources) != 3 || dict.Sources[0] != filepath.Join(dir, "high.dic") {
+		t.Errorf("bad sources %v", dict.Sources)
+	}
+	word, _ = dict.FindWord("...")
+	if word == nil {
+		t.Errorf("builtin words not added")
+	}
+
+	context, err := TokenizeNewManifestContext(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("cannot create context %s", err)
+	}
+	word, _ = context.GetDictionary().FindWord("test")
+	if word == nil {
+		t.Errorf("test not in context dictionary")
+	}
+}
+
+func TestManifestInvalid(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "manifest.json")
+
+	ioutil.WriteFile(path, []byte(`{"sources": [{"path": "a.dic", "language": "de"}]}`), 0666)
+	_, err := ReadManifest(path)
+	if err == nil {
+		t.Errorf("unknown language accepted")
+	}
+
+	ioutil.WriteFile(path, []byte(`{"policy": "ignore", "sources": []}`), 0666)
+	_, err = ReadManifest(path)
+	if err == nil {
+		t.Errorf("unknown policy accepted")
+	}
+}
+
+// the manifest of the repository reads the files of DefaultManifest
+// run from the repository root
+func TestManifestRepository(t *testing.T) {
+	m, err := ReadManifest(filepath.Join("..", "lm", "manifest.json"))
+	if err != nil {
+		t.Fatalf("cannot read repository manifest %s", err)
+	}
+	defaults := DefaultManifest()
+
+	if m.Resolve(m.Binary) != filepath.Join("..", defaults.Binary) {
+		t.Errorf("binary resolves to %s", m.Resolve(m.Binary))
+	}
+	if len(m.Sources) != len(defaults.Sources) {
+		t.Fatalf("%d sources, expected %d", len(m.Sources), len(defaults.Sources))
+	}
+	for i, source := range m.Sources {
+		expected := defaults.Sources[i]
+		if m.Resolve(source.Path) != filepath.Join("..", expected.Path) {
+			t.Errorf("source %d resolves to %s", i, m.Resolve(source.Path))
+		}
+		if source.Language != expected.Language || source.GetFormat() != expected.GetFormat() {
+			t.Errorf("source %s is %s %s", source.Path, source.Language, source.GetFormat())
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package words
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// names of a feature value: the DELA code used by String and
+// ParseVariant, then english and french labels
+type featureName struct {
+	value   byte
+	code    string
+	english string
+	french  string
+}
+
+// tags without DELA code use the name of the dela xml files
+var tagNames = []featureName{
+	{NOUN, "N", "noun", "nom"},
+	{PREP, "PREP", "preposition", "préposition"},
+	{ADVERB, "ADV", "adverb", "adverbe"},
+	{VERB, "V", "verb", "verbe"},
+	{ADJ, "A", "adjective", "adjectif"},
+	{NOMINALDET, "NDET", "nominal determiner", "déterminant nominal"},
+	{PREFIX, "PFX", "prefix", "préfixe"},
+	{GNP, "GNP", "noun phrase (GNP)", "groupe nominal (GNP)"},
+	{GNPX, "GNPX", "noun phrase (GNPX)", "groupe nominal (GNPX)"},
+	{CONJS, "CONJS", "subordinating conjunction", "conjonction de subordination"},
+	{CONJ, "CONJ", "conjunction", "conjonction"},
+	{GN, "GN", "noun phrase", "groupe nominal"},
+	{CONJC, "CONJC", "coordinating conjunction", "conjonction de coordination"},
+	{PRONOUN, "PRO", "pronoun", "pronom"},
+	{PREPADJ, "PREPADJ", "adjectival preposition", "préposition adjectivale"},
+	{PREPDET, "PREPDET", "contracted article", "article contracté"},
+	{PREPPRO, "PREPPRO", "prepositional pronoun", "pronom prépositionnel"},
+	{INTJ, "INTJ", "interjection", "interjection"},
+	{DET, "DET", "determiner", "déterminant"},
+	{PRON, "PRON", "pronoun (PRON)", "pronom (PRON)"},
+	{VA, "VA", "verbal phrase (VA)", "locution verbale (VA)"},
+	{NA, "NA", "noun phrase (NA)", "locution nominale (NA)"},
+	{CLARKN, "CLARKN", "proper name (Clark)", "nom propre (Clark)"},
+	{GHERYN, "GHERYN", "proper name (Ghery)", "nom propre (Ghery)"},
+	{GWELLSN, "GWELLSN", "proper name (G. Wells)", "nom propre (G. Wells)"},
+	{MAYERN, "MAYERN", "proper name (Mayer)", "nom propre (Mayer)"},
+	{NES, "NES", "named entity", "entité nommée"},
+	{MAUGHAMN, "MAUGHAMN", "proper name (Maugham)", "nom propre (Maugham)"},
+	{ADVA, "ADVA", "adverbial phrase (ADVA)", "locution adverbiale (ADVA)"},
+	{CFIELDSN, "CFIELDSN", "proper name (C. Fields)", "nom propre (C. Fields)"},
+	{X, "X", "other", "autre"},
+	{PCDN3, "PCDN3", "compound noun (PCDN3)", "nom composé (PCDN3)"},
+	{XI, "XI", "other (XI)", "autre (XI)"},
+	{PART, "PART", "particle", "particule"},
+	{PRED, "PRED", "predicate", "prédicat"},
+	{HARTN, "HARTN", "proper name (Hart)", "nom propre (Hart)"},
+	{ABBR, "ABBR", "abbreviation", "abréviation"},
+	{TAB, "TAB", "tab", "tabulation"},
+	{QUOTATIONMARK, "QUOTATIONMARK", "quotation mark", "guillemet"},
+	{BEGINQUOTATION, "BEGINQUOTATION", "opening quotation mark", "guillemet ouvrant"},
+	{ENDQUOTATION, "ENDQUOTATION", "closing quotation mark", "guillemet fermant"},
+	{APOS, "APOS", "apostrophe", "apostrophe"},
+	{SLASH, "SLASH", "slash", "barre oblique"},
+	{DASH, "DASH", "dash", "tiret"},
+	{GREATHERTHAN, "GREATERTHAN", "greater than sign", "signe supérieur"},
+	{SMALLERTHAN, "SMALLERTHAN", "less than sign", "signe inférieur"},
+	{SPACE, "SPACE", "space", "espace"},
+	{COMMA, "COMMA", "comma", "virgule"},
+	{SEMICOLON, "SEMICOLON", "semicolon", "point-virgule"},
+	{DOT, "DOT", "dot", "point"},
+	{COLON, "COLON", "colon", "deux-points"},
+	{EXCLAMATIONMARK, "EXCLAMATIONMARK", "exclamation mark", "point d'exclamation"},
+	{QUESTIONMARK, "QUESTIONMARK", "question mark", "point d'interrogation"},
+	{BEGINPARENTHESIS, "BEGINPARENTHESIS", "opening parenthesis", "parenthèse ouvrante"},
+	{ENDPARENTHESIS, "ENDPARENTHESIS", "closing parenthesis", "parenthèse fermante"},
+	{BEGINBRACKET, "BEGINBRACKET", "opening bracket", "crochet ouvrant"},
+	{ENDBRACKET, "ENDBRACKET", "closing bracket", "crochet fermant"},
+	{NUMBERSIGN, "NUMBERSIGN", "number sign", "croisillon"},
+	{DOLLARSIGN, "DOLLARSIGN", "dollar sign", "signe dollar"},
+	{COPYRIGHTSIGN, "COPYRIGHTSIGN", "copyright sign", "signe copyright"},
+	{ATSIGN, "ATSIGN", "at sign", "arobase"},
+	{NBNS, "NBNS", "non-breaking space", "espace insécable"},
+	{AND, "AND", "ampersand", "esperluette"},
+	{OR, "OR", "vertical bar", "barre verticale"},
+	{EOL, "EOL", "end of line", "fin de ligne"},
+	{CR, "CR", "carriage return", "retour chariot"},
+}
+
+// the first DELAF letter of a tense is the one written
+var tenseNames = []featureName{
+	{IND, "P", "indicative", "indicatif"},
+	{GERONDIF, "G", "gerund", "gérondif"},
+	{SUBJ, "S", "subjunctive", "subjonctif"},
+	{PPAST, "K", "past participle", "participe passé"},
+	{IMP, "Y", "imperative", "impératif"},
+	{COND, "C", "conditional", "conditionnel"},
+	{INF, "W", "infinitive", "infinitif"},
+}
+
+var genderNames = []featureName{
+	{MALE, "m", "masculine", "masculin"},
+	{FEMALE, "f", "feminine", "féminin"},
+}
+
+var numberNames = []featureName{
+	{SINGULAR, "s", "singular", "singulier"},
+	{PLURAL, "p", "plural", "pluriel"},
+}
+
+// number after a person, 3rd pers. sing.
+var personNumberNames = []featureName{
+	{SINGULAR, "s", "sing.", "sing."},
+	{PLURAL, "p", "plur.", "plur."},
+}
+
+var personNames = []featureName{
+	{1, "1", "1st pers.", "1re pers."},
+	{2, "2", "2nd pers.", "2e pers."},
+	{3, "3", "3rd pers.", "3e pers."},
+}
+
+var subcatNames = []featureName{
+	{HUMAN, "Hum", "human", "humain"},
+	{ANIMAL, "Anl", "animal", "animal"},
+	{CONCRET, "Conc", "concrete", "concret"},
+	{ABSTRACT, "Abst", "abstract", "abstrait"},
+	{UNIT, "Unit", "unit", "unité"},
+	{INDEFINITE, "Indef", "indefinite", "indéfini"},
+	{TEMPORAL, "Temp", "temporal", "temporel"},
+	{DEMONSTRATIVE, "Dem", "demonstrative", "démonstratif"},
+}
+
+var flagNames = []featureName{
+	{PROPER, "PR", "proper", "propre"},
+	{SUBCAT, "Subcat", "subcategorized", "sous-catégorisé"},
+	{COMPOUND, "Comp", "compound", "composé"},
+	{COLL, "Col", "coll", "coll"},
+	{POSTPOS, "Post", "postposed", "postposé"},
+	{COLLECTIVE, "Coll", "collective", "collectif"},
+	{PROCATDEMONSTRATIVE, "ProDem", "demonstrative pronoun", "pronom démonstratif"},
+	{ELIDED, "Elid", "elided", "élidé"},
+}
+
+var languageNames = []featureName{
+	{FRENCH, "fr", "French", "français"},
+	{ENGLISH, "en", "English", "anglais"},
+}
+
+func findFeatureName(names []featureName, value byte) *featureName {
+	for i := range names {
+		if names[i].value == value {
+			return &names[i]
+		}
+	}
+	return nil
+}
+
+func findFeatureCode(names []featureName, code string) *featureName {
+	for i := range names {
+		if names[i].code == code {
+			return &names[i]
+		}
+	}
+	return nil
+}
+
+// label in lang, english unless lang is FRENCH, a value without
+// name is printed as a number
+func (name *featureName) label(lang byte, value byte) string {
+	switch {
+	case name == nil:
+		return fmt.Sprintf("%d", value)
+	case lang == FRENCH:
+		return name.french
+	}
+	return name.english
+}
+
+// DELA code of tag, the number of tag when it has none
+func TagCode(tag byte) string {
+	name := findFeatureName(tagNames, tag)
+	if name == nil {
+		return fmt.Sprintf("%d", tag)
+	}
+	return name.code
+}
+
+// dela escapes of the separators of the syntax
+var variantEscaper = strings.NewReplacer(`\`, `\\`, `.`, `\.`, `+`, `\+`, `:`, `\:`, `,`, `\,`)
+
+// DELAF syntax lemma.POS+codes:inflection, chien.N+Anl:ms, the lemma,
+// the POS and every part are optional, the language is a code fr or en
+func (v *WordVariant) String() string {
+	var b strings.Builder
+	if len(v.Lemma) > 0 {
+		b.WriteString(variantEscaper.Replace(v.Lemma))
+		b.WriteByte('.')
+	}
+	if v.Tag != 0 {
+		b.WriteString(TagCode(v.Tag))
+	}
+	for _, code := range v.codes() {
+		b.WriteString("+" + code)
+	}
+	if name := findFeatureName(languageNames, v.Language); name != nil {
+		b.WriteString("+" + name.code)
+	}
+
+	inflection := v.inflection()
+	if len(inflection) > 0 {
+		b.WriteString(":" + inflection)
+	}
+	return b.String()
+}
+
+// DELAF semantic codes of the subcat and the flags, Anl or PR
+func (v *WordVariant) codes() (codes []string) {
+	if name := findFeatureName(subcatNames, v.Subcat); name != nil {
+		codes = append(codes, name.code)
+	}
+	for _, name := range flagNames {
+		if v.Flags&name.value != 0 {
+			codes = append(codes, name.code)
+		}
+	}
+	return
+}
+
+// DELAF inflection code, tense person gender number: P3s, Kfp, ms
+func (v *WordVariant) inflection() string {
+	var b strings.Builder
+	for _, part := range []struct {
+		names []featureName
+		value byte
+	}{
+		{tenseNames, v.Tense},
+		{personNames, v.Person},
+		{genderNames, v.Gender},
+		{numberNames, v.Number},
+	} {
+		if name := findFeatureName(part.names, part.value); name != nil {
+			b.WriteString(name.code)
+		}
+	}
+	return b.String()
+}
+
+// sets the features of a DELAF inflection code, unknown letters are
+// skipped and give false
+func (v *WordVariant) parseInflection(code string) bool {
+	ok := true
+	for _, c := range code {
+		switch {
+		case c == 'm':
+			v.Gender = MALE
+		case c == 'f':
+			v.Gender = FEMALE
+		case c == 's':
+			v.Number = SINGULAR
+		case c == 'p':
+			v.Number = PLURAL
+		case c >= '1' && c <= '3':
+			v.Person = byte(c - '0')
+		case delafTense[c] != 0:
+			v.Tense = delafTense[c]
+		default:
+			ok = false
+		}
+	}
+	return ok
+}
+
+// readable description in lang: verbe, indicatif, 3e pers. sing.
+func (v *WordVariant) Label(lang byte) string {
+	var parts []string
+	add := func(names []featureName, value byte) {
+		if value != 0 {
+			parts = append(parts, findFeatureName(names, value).label(lang, value))
+		}
+	}
+
+	add(tagNames, v.Tag)
+	add(subcatNames, v.Subcat)
+	for _, name := range flagNames {
+		if v.Flags&name.value != 0 {
+			parts = append(parts, name.label(lang, name.value))
+		}
+	}
+	add(tenseNames, v.Tense)
+	add(genderNames, v.Gender)
+	if v.Person != 0 {
+		person := findFeatureName(personNames, v.Person).label(lang, v.Person)
+		if v.Number != 0 {
+			person += " " + findFeatureName(personNumberNames, v.Number).label(lang, v.Number)
+		}
+		parts = append(parts, person)
+	} else {
+		add(numberNames, v.Number)
+	}
+	add(languageNames, v.Language)
+	return strings.Join(parts, ", ")
+}
+
+// parses the syntax of WordVariant.String, inflection codes are also
+// accepted after + as in V+P3s and the POS codes of DELAF files are
+// accepted, zero features of the result match any value in a filter
+func ParseVariant(s string) (v WordVariant, err error) {
+	codes := s
+	lemma, rest, found := delafSplit(s, ".")
+	if found {
+		v.Lemma = lemma
+		codes = rest[1:]
+	}
+
+	gram, inflection, _ := delafSplit(codes, ":")
+	fields := strings.Split(gram, "+")
+	if pos := fields[0]; len(pos) > 0 {
+		if name := findFeatureCode(tagNames, pos); name != nil {
+			v.Tag = name.value
+		} else if tag, ok := delafPos[pos]; ok {
+			v.Tag = tag
+		} else if tag, err := strconv.ParseUint(pos, 10, 8); err == nil {
+			v.Tag = byte(tag)
+		} else {
+			return v, fmt.Errorf("unknown part of speech '%s' in '%s'", pos, s)
+		}
+	}
+
+	for _, code := range fields[1:] {
+		if name := findFeatureCode(subcatNames, code); name != nil {
+			v.Subcat = name.value
+		} else if name := findFeatureCode(flagNames, code); name != nil {
+			v.Flags |= name.value
+		} else if name := findFeatureCode(languageNames, code); name != nil {
+			v.Language = name.value
+		} else if len(code) == 0 || !v.parseInflection(code) {
+			return v, fmt.Errorf("unknown code '%s' in '%s'", code, s)
+		}
+	}
+
+	if len(inflection) > 0 {
+		code := inflection[1:]
+		if strings.ContainsRune(code, ':') {
+			return v, fmt.Errorf("several inflections in '%s'", s)
+		}
+		if !v.parseInflection(code) {
+			return v, fmt.Errorf("unknown inflection '%s' in '%s'", code, s)
+		}
+	}
+	return v, nil
+}
+
+// filter parsed with ParseVariant, nil for an empty string
+func ParseFilter(s string) (*WordVariant, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	v, err := ParseVariant(s)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
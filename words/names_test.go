@@ -0,0 +1,137 @@
+package words
+
+import (
+	"testing"
+)
+
+func TestVariantString(t *testing.T) {
+	expected := []struct {
+		variant WordVariant
+		s       string
+	}{
+		{WordVariant{Tag: VERB, Tense: IND, Person: 3, Number: SINGULAR}, "V:P3s"},
+		{WordVariant{Tag: NOUN, Gender: FEMALE, Number: PLURAL, Lemma: "chien"}, "chien.N:fp"},
+		{WordVariant{Tag: NOUN, Subcat: HUMAN, Flags: PROPER, Language: FRENCH}, "N+Hum+PR+fr"},
+		{WordVariant{Tag: VERB, Tense: INF, Lemma: "s'en aller"}, "s'en aller.V:W"},
+		{WordVariant{Tag: ABBR, Lemma: "M."}, `M\..ABBR`},
+		{WordVariant{Gender: MALE}, ":m"},
+		{WordVariant{}, ""},
+	}
+	for _, e := range expected {
+		if e.variant.String() != e.s {
+			t.Errorf("%+v prints %s not %s", e.variant, e.variant.String(), e.s)
+		}
+		parsed, err := ParseVariant(e.s)
+		if err != nil || !parsed.Equals(&e.variant) {
+			t.Errorf("%s parses to %+v %v", e.s, parsed, err)
+		}
+	}
+}
+
+func TestVariantNames(t *testing.T) {
+	// every constant round trips through its code
+	tables := []struct {
+		names []featureName
+		set   func(v *WordVariant, value byte)
+	}{
+		{tagNames, func(v *WordVariant, value byte) { v.Tag = value }},
+		{tenseNames, func(v *WordVariant, value byte) { v.Tense = value }},
+		{genderNames, func(v *WordVariant, value byte) { v.Gender = value }},
+		{numberNames, func(v *WordVariant, value byte) { v.Number = value }},
+		{personNames, func(v *WordVariant, value byte) { v.Person = value }},
+		{subcatNames, func(v *WordVariant, value byte) { v.Subcat = value }},
+		{flagNames, func(v *WordVariant, value byte) { v.Flags = value }},
+		{languageNames, func(v *WordVariant, value byte) { v.Language = value }},
+	}
+	for _, table := range tables {
+		codes := make(map[string]bool)
+		for _, name := range table.names {
+			if codes[name.code] {
+				t.Errorf("duplicate code %s", name.code)
+			}
+			codes[name.code] = true
+
+			v := WordVariant{}
+			table.set(&v, name.value)
+			parsed, err := ParseVariant(v.String())
+			if err != nil || !parsed.Equals(&v) {
+				t.Errorf("%s does not round trip %v", v.String(), err)
+			}
+		}
+	}
+	for tag := byte(NOUN); tag <= CR; tag++ {
+		if findFeatureName(tagNames, tag) == nil {
+			t.Errorf("tag %d without name", tag)
+		}
+	}
+}
+
+func TestParseVariant(t *testing.T) {
+	expected := []struct {
+		s       string
+		variant WordVariant
+	}{
+		{"V+P3s", WordVariant{Tag: VERB, Tense: IND, Person: 3, Number: SINGULAR}},
+		{"V:I3p", WordVariant{Tag: VERB, Tense: IND, Person: 3, Number: PLURAL}},
+		{"ABR", WordVariant{Tag: ABBR}},
+		{"A+Coll:fp", WordVariant{Tag: ADJ, Flags: COLLECTIVE, Gender: FEMALE, Number: PLURAL}},
+		{"4", WordVariant{Tag: VERB}},
+	}
+	for _, e := range expected {
+		v, err := ParseVariant(e.s)
+		if err != nil || !v.Equals(&e.variant) {
+			t.Errorf("%s parses to %+v %v", e.s, v, err)
+		}
+	}
+
+	for _, s := range []string{"Z", "V+Foo", "V:P3s:S3s", "N:mx", "V+"} {
+		_, err := ParseVariant(s)
+		if err == nil {
+			t.Errorf("%s should not parse", s)
+		}
+	}
+
+	filter, err := ParseFilter("N:p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := GetLemmaDictionary()
+	words := dict.AutoComplete("chien", filter)
+	if len(words) != 0 {
+		t.Errorf("Filter needs a language %d", len(words))
+	}
+	filter, _ = ParseFilter("N+fr:p")
+	words = dict.AutoComplete("chien", filter)
+	if len(words) != 2 {
+		t.Errorf("bad filtered completions %d", len(words))
+	}
+	filter, err = ParseFilter("")
+	if filter != nil || err != nil {
+		t.Errorf("empty filter")
+	}
+}
+
+func TestVariantLabel(t *testing.T) {
+	v := WordVariant{Tag: VERB, Tense: IND, Person: 3, Number: SINGULAR}
+	if v.Label(FRENCH) != "verbe, indicatif, 3e pers. sing." {
+		t.Errorf("bad french label %s", v.Label(FRENCH))
+	}
+	if v.Label(ENGLISH) != "verb, indicative, 3rd pers. sing." {
+		t.Errorf("bad english label %s", v.Label(ENGLISH))
+	}
+	v = WordVariant{Tag: NOUN, Subcat: ANIMAL, Gender: FEMALE, Number: PLURAL, Language: FRENCH}
+	if v.Label(FRENCH) != "nom, animal, féminin, pluriel, français" {
+		t.Errorf("bad french label %s", v.Label(FRENCH))
+	}
+	v = WordVariant{Tag: 200}
+	if v.Label(ENGLISH) != "200" {
+		t.Errorf("bad unknown label %s", v.Label(ENGLISH))
+	}
+
+	word := Word{}
+	word.form = "chiennes"
+	word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Gender: FEMALE, Number: PLURAL, Lemma: "chien"})
+	if word.Description() != "[chiennes[chien.N:fp]]" {
+		t.Errorf("bad description %s", word.Description())
+	}
+}
@@ -0,0 +1,129 @@
+package words
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// value of NUMBER and ROMAN tokens
+type NumberValue struct {
+	Value    float64
+	Ordinal  bool   // 1er, 2e, XXIe
+	Unit     string // % or ‰
+	Currency string // symbol or code as written, € or EUR
+}
+
+// french ordinal suffixes, longest first
+var ordinalSuffixes = []string{"ème", "ère", "nde", "ᵉʳ", "er", "re", "nd", "ᵉ", "e"}
+
+var numberUnits = []string{"%", "‰"}
+
+var numberCurrencies = []string{"€", "$", "£", "¥", "EUR", "USD", "CAD", "GBP", "CHF", "JPY"}
+
+// spaces between thousands and before a unit
+func numberSpace(s string) int {
+	r, l := utf8.DecodeRuneInString(s)
+	if r == ' ' || r == '\u00a0' || r == '\u202f' {
+		return l
+	}
+	return 0
+}
+
+// end of the digits of s from i
+func numberDigits(s string, i int) int {
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+// true when s does not go on with a letter or a digit
+func numberEnds(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return len(s) == 0 || !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// length of the prefix of s in list ending a word, 0 when none
+func numberSuffix(s string, list []string) int {
+	for _, suffix := range list {
+		if strings.HasPrefix(s, suffix) && numberEnds(s[len(suffix):]) {
+			return len(suffix)
+		}
+	}
+	return 0
+}
+
+// the french number at the start of s and its length: thousands
+// separated by spaces, a decimal comma, then an ordinal suffix or a
+// unit, 0 when s does not start with a digit
+func ScanNumber(s string) (n int, number NumberValue) {
+	i := numberDigits(s, 0)
+	if i == 0 {
+		return 0, number
+	}
+	value := s[:i]
+
+	// 10 000 after 1 to 3 digits
+	if i <= 3 {
+		for {
+			l := numberSpace(s[i:])
+			if l == 0 {
+				break
+			}
+			k := numberDigits(s, i+l)
+			if k-i-l != 3 {
+				break
+			}
+			value += s[i+l : k]
+			i = k
+		}
+	}
+
+	decimal := false
+	if i+1 < len(s) && s[i] == ',' {
+		if k := numberDigits(s, i+1); k > i+1 {
+			value += "." + s[i+1:k]
+			i = k
+			decimal = true
+		}
+	}
+	number.Value, _ = strconv.ParseFloat(value, 64)
+
+	if !decimal {
+		if l := numberSuffix(s[i:], ordinalSuffixes); l > 0 {
+			number.Ordinal = true
+			return i + l, number
+		}
+	}
+
+	j := i + numberSpace(s[i:])
+	if l := numberSuffix(s[j:], numberUnits); l > 0 {
+		number.Unit = s[j : j+l]
+		return j + l, number
+	}
+	if l := numberSuffix(s[j:], numberCurrencies); l > 0 {
+		number.Currency = s[j : j+l]
+		return j + l, number
+	}
+	return i, number
+}
+
+// token of the number started at i when it goes on after a space or a
+// comma as 10 000 € or 3,14, or with a unit or a currency glued to it
+// as 12%, nil otherwise
+func TokenizeNumber(content string, i int, context *TokenizeContext) *Token {
+	n, number := ScanNumber(content[i:])
+	end := i + n
+	glued := number.Unit != "" || number.Currency != ""
+	if n == 0 || !glued && !strings.ContainsAny(content[i:end], " ,\u00a0\u202f") {
+		return nil
+	}
+	r, _ := utf8.DecodeRuneInString(content[end:])
+	if end < len(content) && (r == '&' || !unicode.IsPunct(r) && !unicode.IsSpace(r)) {
+		return nil
+	}
+	searchPath := true
+	return TokenizeBuildToken(content, &searchPath, i, end, context)
+}
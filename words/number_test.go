@@ -0,0 +1,85 @@
+package words
+
+import (
+	"testing"
+)
+
+func TestScanNumber(t *testing.T) {
+	tests := []struct {
+		s      string
+		n      int
+		number NumberValue
+	}{
+		{"3,14", 4, NumberValue{Value: 3.14}},
+		{"10 000 €", 10, NumberValue{Value: 10000, Currency: "€"}},
+		{"1\u202f234\u00a0567,5", 14, NumberValue{Value: 1234567.5}},
+		{"12,5 %", 6, NumberValue{Value: 12.5, Unit: "%"}},
+		{"20‰", 5, NumberValue{Value: 20, Unit: "‰"}},
+		{"15 EUR", 6, NumberValue{Value: 15, Currency: "EUR"}},
+		{"1er", 3, NumberValue{Value: 1, Ordinal: true}},
+		{"1re", 3, NumberValue{Value: 1, Ordinal: true}},
+		{"2e", 2, NumberValue{Value: 2, Ordinal: true}},
+		{"3ème", 5, NumberValue{Value: 3, Ordinal: true}},
+		{"2nde", 4, NumberValue{Value: 2, Ordinal: true}},
+		// stops before what is not part of the number
+		{"1998 300", 4, NumberValue{Value: 1998}},
+		{"12 3456", 2, NumberValue{Value: 12}},
+		{"1, 2", 1, NumberValue{Value: 1}},
+		{"3,14e", 4, NumberValue{Value: 3.14}},
+		{"12 EURO", 2, NumberValue{Value: 12}},
+		{"12h20", 2, NumberValue{Value: 12}},
+		{"abc", 0, NumberValue{}},
+	}
+	for _, test := range tests {
+		n, number := ScanNumber(test.s)
+		if n != test.n || number != test.number {
+			t.Errorf("%q scanned as %d %+v", test.s, n, number)
+		}
+	}
+}
+
+func TestTokenizeNumber(t *testing.T) {
+	dict := GetLemmaDictionary()
+	dict.AddWordWithTag(" ", SPACE)
+	dict.AddWordWithTag(",", COMMA)
+	dict.AddWordWithTag(".", DOT)
+	context := NewTestContext(dict)
+
+	text := "chiens 3,14 12,5 % XXIe 1er, 2e 1, 2 12h20 12%, 5‰ 3,5% 10 000 €"
+	tokens := Tokenize(text, context, true)
+	var contents []string
+	for _, token := range tokens {
+		if !TokenizeIsSpace(token) {
+			contents = append(contents, token.Content(text))
+		}
+	}
+	expected := []string{"chiens", "3,14", "12,5 %", "XXIe", "1er", ",", "2e", "1", ",", "2", "12h20", "12%", ",", "5‰", "3,5%", "10 000 €"}
+	if len(contents) != len(expected) {
+		t.Fatalf("bad tokens %q", contents)
+	}
+	for i := range expected {
+		if contents[i] != expected[i] {
+			t.Errorf("bad token %q should be %q", contents[i], expected[i])
+		}
+	}
+
+	values := map[string]NumberValue{
+		"3,14":     {Value: 3.14},
+		"12,5 %":   {Value: 12.5, Unit: "%"},
+		"XXIe":     {Value: 21, Ordinal: true},
+		"1er":      {Value: 1, Ordinal: true},
+		"12%":      {Value: 12, Unit: "%"},
+		"5‰":       {Value: 5, Unit: "‰"},
+		"3,5%":     {Value: 3.5, Unit: "%"},
+		"10 000 €": {Value: 10000, Currency: "€"},
+	}
+	for _, token := range tokens {
+		value, ok := values[token.Content(text)]
+		if ok && (token.Kind == 0 || token.Value != value) {
+			t.Errorf("%s bad value %s %+v", token.Content(text), token.Kind, token.Value)
+		}
+	}
+	if tokens[len(tokens)-1].Kind != NUMBER || tokens[6].Kind != ROMAN {
+		t.Errorf("bad kinds %s %s", tokens[len(tokens)-1].Kind, tokens[6].Kind)
+	}
+}
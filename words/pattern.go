@@ -0,0 +1,455 @@
+package words
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// a glob or regular expression compiled to an automaton run along the
+// trie, subtrees no state can reach are never visited
+//
+// globs match whole forms: ? any letter, * any letters, [abc], [a-z]
+// and [!a] letter classes
+//
+// regular expressions start with ^ and match forms from their first
+// letter, up to the end with $: . [] [^] * + ? {n} {n,} {n,m} | ()
+// and \ escapes
+type Pattern struct {
+	Source string
+
+	states []patternState
+	start  int
+}
+
+// automaton states
+const (
+	patternMatch = iota
+	patternRune
+	patternAny
+	patternClass
+	patternSplit
+)
+
+type patternState struct {
+	kind  byte
+	r     rune
+	class *patternSet
+	out   int
+	out1  int // second branch of a split
+}
+
+type patternSet struct {
+	negated bool
+	ranges  []rune // pairs of first and last rune
+}
+
+func (class *patternSet) contains(r rune) bool {
+	for i := 0; i < len(class.ranges); i += 2 {
+		if r >= class.ranges[i] && r <= class.ranges[i+1] {
+			return !class.negated
+		}
+	}
+	return class.negated
+}
+
+// expression tree built by the parsers
+type patternNode struct {
+	kind     byte // patternRune, patternAny, patternClass or one below
+	r        rune
+	class    *patternSet
+	children []*patternNode
+	min, max int // repeat bounds, max < 0 is unbounded
+}
+
+const (
+	patternConcat = iota + patternSplit + 1
+	patternAlt
+	patternRepeat
+)
+
+// repeat limit of {n,m}, the automaton grows with the bounds
+const patternMaxRepeat = 100
+
+func ParsePattern(pattern string) (*Pattern, error) {
+	if strings.HasPrefix(pattern, "^") {
+		return ParseRegexp(pattern)
+	}
+	return ParseGlob(pattern)
+}
+
+func ParseGlob(glob string) (*Pattern, error) {
+	var nodes []*patternNode
+	for i := 0; i < len(glob); {
+		r, w := utf8.DecodeRuneInString(glob[i:])
+		switch r {
+		case '?':
+			nodes = append(nodes, &patternNode{kind: patternAny})
+		case '*':
+			anyRune := &patternNode{kind: patternAny}
+			nodes = append(nodes, &patternNode{kind: patternRepeat, children: []*patternNode{anyRune}, max: -1})
+		case '[':
+			class, n, err := parsePatternClass(glob[i:], "!^")
+			if err != nil {
+				return nil, fmt.Errorf("glob '%s' at %d: %s", glob, i, err)
+			}
+			nodes = append(nodes, &patternNode{kind: patternClass, class: class})
+			w = n
+		case '\\':
+			if i+w < len(glob) {
+				i += w
+				r, w = utf8.DecodeRuneInString(glob[i:])
+			}
+			nodes = append(nodes, &patternNode{kind: patternRune, r: r})
+		default:
+			nodes = append(nodes, &patternNode{kind: patternRune, r: r})
+		}
+		i += w
+	}
+	return compilePattern(glob, &patternNode{kind: patternConcat, children: nodes}), nil
+}
+
+func ParseRegexp(expr string) (*Pattern, error) {
+	parser := patternParser{strings.TrimPrefix(expr, "^"), 0}
+	if len(parser.s) == len(expr) {
+		return nil, fmt.Errorf("regexp '%s' must start with ^", expr)
+	}
+
+	anchored := false
+	if strings.HasSuffix(parser.s, "$") && !strings.HasSuffix(parser.s, "\\$") {
+		parser.s = parser.s[:len(parser.s)-1]
+		anchored = true
+	}
+
+	node, err := parser.alt()
+	if err == nil && parser.pos < len(parser.s) {
+		err = fmt.Errorf("unexpected '%c'", parser.s[parser.pos])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("regexp '%s' at %d: %s", expr, parser.pos+1, err)
+	}
+
+	if !anchored {
+		anyRune := &patternNode{kind: patternAny}
+		node = &patternNode{kind: patternConcat, children: []*patternNode{node,
+			{kind: patternRepeat, children: []*patternNode{anyRune}, max: -1}}}
+	}
+	return compilePattern(expr, node), nil
+}
+
+// parses [...] at the start of s, negate lists the negation marks
+func parsePatternClass(s string, negate string) (class *patternSet, n int, err error) {
+	class = new(patternSet)
+	i := 1
+	if i < len(s) && strings.IndexByte(negate, s[i]) >= 0 {
+		class.negated = true
+		i++
+	}
+	first := true
+	for i < len(s) && (s[i] != ']' || first) {
+		first = false
+		r, w := utf8.DecodeRuneInString(s[i:])
+		if r == '\\' && i+w < len(s) {
+			i += w
+			r, w = utf8.DecodeRuneInString(s[i:])
+		}
+		i += w
+		last := r
+		if i+1 < len(s) && s[i] == '-' && s[i+1] != ']' {
+			last, w = utf8.DecodeRuneInString(s[i+1:])
+			i += 1 + w
+			if last < r {
+				return nil, 0, fmt.Errorf("bad range %c-%c", r, last)
+			}
+		}
+		class.ranges = append(class.ranges, r, last)
+	}
+	if i >= len(s) {
+		return nil, 0, fmt.Errorf("missing ]")
+	}
+	return class, i + 1, nil
+}
+
+type patternParser struct {
+	s   string
+	pos int
+}
+
+func (p *patternParser) peek() byte {
+	if p.pos < len(p.s) {
+		return p.s[p.pos]
+	}
+	return 0
+}
+
+// alt := concat ('|' concat)*
+func (p *patternParser) alt() (*patternNode, error) {
+	var children []*patternNode
+	for {
+		node, err := p.concat()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+		if p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &patternNode{kind: patternAlt, children: children}, nil
+}
+
+// concat := repeat*
+func (p *patternParser) concat() (*patternNode, error) {
+	var children []*patternNode
+	for p.pos < len(p.s) && p.peek() != '|' && p.peek() != ')' {
+		node, err := p.repeat()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, node)
+	}
+	return &patternNode{kind: patternConcat, children: children}, nil
+}
+
+// repeat := atom ('*' | '+' | '?' | '{n}' | '{n,}' | '{n,m}')*
+func (p *patternParser) repeat() (*patternNode, error) {
+	node, err := p.atom()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		min, max := 0, 0
+		switch p.peek() {
+		case '*':
+			min, max = 0, -1
+		case '+':
+			min, max = 1, -1
+		case '?':
+			min, max = 0, 1
+		case '{':
+			min, max, err = p.bounds()
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return node, nil
+		}
+		p.pos++
+		node = &patternNode{kind: patternRepeat, children: []*patternNode{node}, min: min, max: max}
+	}
+}
+
+// parses {n}, {n,} or {n,m} and stops on the closing brace
+func (p *patternParser) bounds() (min int, max int, err error) {
+	end := strings.IndexByte(p.s[p.pos:], '}')
+	if end < 0 {
+		return 0, 0, fmt.Errorf("missing }")
+	}
+	spec := p.s[p.pos+1 : p.pos+end]
+	low, high, comma := strings.Cut(spec, ",")
+	min, err = strconv.Atoi(low)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad repeat {%s}", spec)
+	}
+	max = min
+	if comma {
+		max = -1
+		if len(high) > 0 {
+			max, err = strconv.Atoi(high)
+			if err != nil || max < min {
+				return 0, 0, fmt.Errorf("bad repeat {%s}", spec)
+			}
+		}
+	}
+	if min > patternMaxRepeat || max > patternMaxRepeat {
+		return 0, 0, fmt.Errorf("repeat {%s} over %d", spec, patternMaxRepeat)
+	}
+	p.pos += end
+	return min, max, nil
+}
+
+// atom := '.' | class | '(' alt ')' | '\' rune | rune
+func (p *patternParser) atom() (*patternNode, error) {
+	r, w := utf8.DecodeRuneInString(p.s[p.pos:])
+	switch r {
+	case '.':
+		p.pos += w
+		return &patternNode{kind: patternAny}, nil
+	case '[':
+		class, n, err := parsePatternClass(p.s[p.pos:], "^")
+		if err != nil {
+			return nil, err
+		}
+		p.pos += n
+		return &patternNode{kind: patternClass, class: class}, nil
+	case '(':
+		p.pos += w
+		node, err := p.alt()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing )")
+		}
+		p.pos++
+		return node, nil
+	case '*', '+', '?', '{':
+		return nil, fmt.Errorf("nothing to repeat")
+	case '^', '$':
+		return nil, fmt.Errorf("anchor inside expression")
+	case '\\':
+		p.pos += w
+		if p.pos >= len(p.s) {
+			return nil, fmt.Errorf("trailing \\")
+		}
+		r, w = utf8.DecodeRuneInString(p.s[p.pos:])
+	}
+	p.pos += w
+	return &patternNode{kind: patternRune, r: r}, nil
+}
+
+func compilePattern(source string, node *patternNode) *Pattern {
+	p := Pattern{}
+	p.Source = source
+	p.states = append(p.states, patternState{kind: patternMatch})
+	p.start = p.compile(node, 0)
+	return &p
+}
+
+func (p *Pattern) add(state patternState) int {
+	p.states = append(p.states, state)
+	return len(p.states) - 1
+}
+
+// builds the states of node in front of next and returns the first
+func (p *Pattern) compile(node *patternNode, next int) int {
+	switch node.kind {
+	case patternRune, patternAny, patternClass:
+		return p.add(patternState{kind: node.kind, r: node.r, class: node.class, out: next})
+	case patternConcat:
+		for i := len(node.children) - 1; i >= 0; i-- {
+			next = p.compile(node.children[i], next)
+		}
+		return next
+	case patternAlt:
+		start := p.compile(node.children[len(node.children)-1], next)
+		for i := len(node.children) - 2; i >= 0; i-- {
+			start = p.add(patternState{kind: patternSplit, out: p.compile(node.children[i], next), out1: start})
+		}
+		return start
+	case patternRepeat:
+		child := node.children[0]
+		if node.max < 0 {
+			loop := p.add(patternState{kind: patternSplit, out1: next})
+			p.states[loop].out = p.compile(child, loop)
+			next = loop
+		} else {
+			for i := node.min; i < node.max; i++ {
+				next = p.add(patternState{kind: patternSplit, out: p.compile(child, next), out1: next})
+			}
+		}
+		for i := 0; i < node.min; i++ {
+			next = p.compile(child, next)
+		}
+		return next
+	}
+	return next
+}
+
+// adds state and the states reached without reading a letter
+func (p *Pattern) closure(set []int, seen []bool, state int) []int {
+	if seen[state] {
+		return set
+	}
+	seen[state] = true
+	if p.states[state].kind == patternSplit {
+		set = p.closure(set, seen, p.states[state].out)
+		return p.closure(set, seen, p.states[state].out1)
+	}
+	return append(set, state)
+}
+
+func (p *Pattern) step(set []int, r rune) []int {
+	var next []int
+	seen := make([]bool, len(p.states))
+	for _, s := range set {
+		state := &p.states[s]
+		switch state.kind {
+		case patternRune:
+			if state.r != r {
+				continue
+			}
+		case patternAny:
+		case patternClass:
+			if !state.class.contains(r) {
+				continue
+			}
+		default:
+			continue
+		}
+		next = p.closure(next, seen, state.out)
+	}
+	return next
+}
+
+func (p *Pattern) matched(set []int) bool {
+	for _, s := range set {
+		if p.states[s].kind == patternMatch {
+			return true
+		}
+	}
+	return false
+}
+
+// true when the whole form matches
+func (p *Pattern) MatchString(form string) bool {
+	set := p.closure(nil, make([]bool, len(p.states)), p.start)
+	for _, r := range form {
+		set = p.step(set, r)
+		if len(set) == 0 {
+			return false
+		}
+	}
+	return p.matched(set)
+}
+
+func (p *Pattern) walk(letter *WordLetter, set []int, fn func(*Word)) {
+	for _, r := range letter.sortedRunes() {
+		child := letter.Children[r]
+		next := p.step(set, r)
+		if len(next) == 0 {
+			continue
+		}
+		if child.Word != nil && p.matched(next) {
+			fn(child.Word)
+		}
+		p.walk(child, next, fn)
+	}
+}
+
+// forms matching pattern with a variant matching filter in rune order,
+// filter may be nil and its zero fields match any value
+func (dict *Dictionary) Match(pattern string, filter *WordVariant) ([]*Word, error) {
+	p, err := ParsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return dict.MatchPattern(p, filter), nil
+}
+
+func (dict *Dictionary) MatchPattern(p *Pattern, filter *WordVariant) []*Word {
+	var words []*Word
+
+	set := p.closure(nil, make([]bool, len(p.states)), p.start)
+	p.walk(&dict.root, set, func(w *Word) {
+		if w.HasVariant(filter) {
+			words = append(words, w)
+		}
+	})
+	return words
+}
@@ -0,0 +1,111 @@
+package words
+
+import (
+	"strings"
+	"testing"
+)
+
+func GetPatternDictionary() *Dictionary {
+	dict := GetCompactTestDictionary()
+	for _, form := range []string{"rapidement", "rarement", "recevoir", "refroidissement", "chaînes"} {
+		tag := byte(ADVERB)
+		if !strings.HasSuffix(form, "ment") || form == "refroidissement" {
+			tag = NOUN
+		}
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: tag, Language: FRENCH, Lemma: form})
+		dict.AddWord(form, &word)
+	}
+	return dict
+}
+
+func TestPatternMatchString(t *testing.T) {
+	expected := []struct {
+		pattern string
+		form    string
+		match   bool
+	}{
+		{"ch?en*", "chien", true},
+		{"ch?en*", "chiennes", true},
+		{"ch?en*", "chen", false},
+		{"*ment", "rarement", true},
+		{"[a-c]hien", "chien", true},
+		{"[!a-c]hien", "chien", false},
+		{"cha\\*", "cha*", true},
+		{"été", "été", true},
+		{"^re.*ment$", "refroidissement", true},
+		{"^re.*ment$", "rarement", false},
+		{"^re", "recevoir", true},
+		{"^chiens?$", "chiens", true},
+		{"^chiens?$", "chienne", false},
+		{"^chien(ne)?s$", "chiennes", true},
+		{"^(lance|chien)s$", "lances", true},
+		{"^(lance|chien)s$", "lancers", false},
+		{"^.{5}$", "lance", true},
+		{"^.{5}$", "lancer", false},
+		{"^l.{2,3}e$", "lance", true},
+		{"^[^aeiou]+$", "ch", true},
+		{"^a+b*$", "aaa", true},
+		{"^\\.$", ".", true},
+	}
+	for _, e := range expected {
+		p, err := ParsePattern(e.pattern)
+		if err != nil {
+			t.Errorf("cannot parse %s %s", e.pattern, err)
+			continue
+		}
+		if p.MatchString(e.form) != e.match {
+			t.Errorf("%s match %s should be %t", e.pattern, e.form, e.match)
+		}
+	}
+}
+
+func TestPatternErrors(t *testing.T) {
+	for _, pattern := range []string{"ch[ie", "^(chien", "^*a", "^a{2", "^a{3,1}", "^a{1000}", "^a$b", "^a\\", "[z-a]"} {
+		_, err := ParsePattern(pattern)
+		if err == nil {
+			t.Errorf("%s should not parse", pattern)
+		}
+	}
+	_, err := ParseRegexp("chien")
+	if err == nil {
+		t.Errorf("regexp without ^ parsed")
+	}
+}
+
+func TestMatch(t *testing.T) {
+	dict := GetPatternDictionary()
+
+	expected := []struct {
+		pattern string
+		filter  *WordVariant
+		words   string
+	}{
+		{"ch?en*", nil, "chien chienne chiennes chiens"},
+		{"*ment", &WordVariant{Tag: ADVERB}, "rapidement rarement"},
+		{"^re.*ment$", nil, "refroidissement"},
+		{"^r", &WordVariant{Tag: ADVERB}, "rapidement rarement"},
+		{"^cha", nil, "chaînes"},
+		{"*s", &WordVariant{Number: PLURAL}, "chiennes chiens"},
+		{"x*", nil, ""},
+	}
+	for _, e := range expected {
+		matched, err := dict.Match(e.pattern, e.filter)
+		if err != nil {
+			t.Errorf("cannot match %s %s", e.pattern, err)
+			continue
+		}
+		var forms []string
+		for _, w := range matched {
+			forms = append(forms, w.String())
+		}
+		if strings.Join(forms, " ") != e.words {
+			t.Errorf("%s matches '%s' not '%s'", e.pattern, strings.Join(forms, " "), e.words)
+		}
+	}
+
+	_, err := dict.Match("^(", nil)
+	if err == nil {
+		t.Errorf("bad pattern matched")
+	}
+}
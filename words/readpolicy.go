@@ -0,0 +1,92 @@
+package words
+
+import (
+	"errors"
+	"fmt"
+)
+
+// policies for entries with unknown attributes
+const (
+	FAILUNKNOWN = 0 // stop reading and return the error
+	SKIPUNKNOWN = 1 // skip the form and count it
+	MAPUNKNOWN  = 2 // map unknown pos to X and drop unknown features
+)
+
+const maxSummaryErrors = 32
+
+// unknown attribute in a dictionary entry
+type VariantError struct {
+	Lemma     string
+	Form      string
+	Attribute string // pos, feature name or inflection code
+	Value     string
+}
+
+func (e *VariantError) Error() string {
+	return fmt.Sprintf("unknown %s '%s' for form '%s' lemma '%s'", e.Attribute, e.Value, e.Form, e.Lemma)
+}
+
+type ReadSummary struct {
+	Entries int // entries or lines read
+	Forms   int // forms added to the dictionary
+	Skipped int // forms skipped with SKIPUNKNOWN
+	Mapped  int // forms added with MAPUNKNOWN
+	Hidden  int // forms of a manifest source hidden by a higher priority
+
+	Unknown map[string]int  // counts by "attribute=value"
+	Errors  []*VariantError // first errors met
+}
+
+func (summary *ReadSummary) String() string {
+	return fmt.Sprintf("entries %d forms %d skipped %d mapped %d hidden %d unknown %v",
+		summary.Entries, summary.Forms, summary.Skipped, summary.Mapped, summary.Hidden, summary.Unknown)
+}
+
+func (summary *ReadSummary) Add(other *ReadSummary) {
+	summary.Entries += other.Entries
+	summary.Forms += other.Forms
+	summary.Skipped += other.Skipped
+	summary.Mapped += other.Mapped
+	summary.Hidden += other.Hidden
+	for key, count := range other.Unknown {
+		if summary.Unknown == nil {
+			summary.Unknown = make(map[string]int)
+		}
+		summary.Unknown[key] += count
+	}
+	for _, e := range other.Errors {
+		if len(summary.Errors) < maxSummaryErrors {
+			summary.Errors = append(summary.Errors, e)
+		}
+	}
+}
+
+// applies policy to the error of a variant, keep tells whether the
+// best effort variant should be added
+func (summary *ReadSummary) apply(policy byte, err error) (keep bool, fatal error) {
+	if err == nil {
+		return true, nil
+	}
+	var variantErr *VariantError
+	if !errors.As(err, &variantErr) || policy == FAILUNKNOWN {
+		return false, err
+	}
+
+	if summary.Unknown == nil {
+		summary.Unknown = make(map[string]int)
+	}
+	summary.Unknown[variantErr.Attribute+"="+variantErr.Value]++
+	if len(summary.Errors) < maxSummaryErrors {
+		summary.Errors = append(summary.Errors, variantErr)
+	}
+
+	switch policy {
+	case SKIPUNKNOWN:
+		summary.Skipped++
+		return false, nil
+	case MAPUNKNOWN:
+		summary.Mapped++
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown policy %d: %w", policy, err)
+}
@@ -0,0 +1,248 @@
+package words
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// kind of entity of a token without word, 0 for words and unknown
+// tokens
+type TokenKind byte
+
+const (
+	NUMBER TokenKind = 1
+	ROMAN  TokenKind = 2
+	HEX    TokenKind = 3
+	TIME   TokenKind = 4
+	DATE   TokenKind = 5
+	TEMP   TokenKind = 6
+	URL    TokenKind = 7
+
+	// first kind free for recognizers outside of the package
+	USERKIND TokenKind = 128
+)
+
+var kindNames = map[TokenKind]string{
+	NUMBER: "number",
+	ROMAN:  "roman",
+	HEX:    "hex",
+	TIME:   "time",
+	DATE:   "date",
+	TEMP:   "temp",
+	URL:    "url",
+}
+
+func (kind TokenKind) String() string {
+	if name, ok := kindNames[kind]; ok {
+		return name
+	}
+	return fmt.Sprintf("kind %d", kind)
+}
+
+// finds an entity in the content of a token, value is its normalized
+// form given as Token.Value
+type Recognizer interface {
+	Recognize(s string) (kind TokenKind, value interface{}, ok bool)
+}
+
+type RecognizerFunc func(s string) (kind TokenKind, value interface{}, ok bool)
+
+func (f RecognizerFunc) Recognize(s string) (kind TokenKind, value interface{}, ok bool) {
+	return f(s)
+}
+
+// normalized values
+type TimeValue struct {
+	Hour   int
+	Minute int
+}
+
+type DateValue struct {
+	Year  int
+	Month int
+	Day   int
+}
+
+type TempValue struct {
+	Degrees int
+	Unit    byte // C, F or K
+}
+
+// a recognizer matching the whole token with a regexp
+type regexpRecognizer struct {
+	kind  TokenKind
+	r     *regexp.Regexp
+	value func(s string, match []string) interface{}
+}
+
+func (r *regexpRecognizer) Recognize(s string) (kind TokenKind, value interface{}, ok bool) {
+	match := r.r.FindStringSubmatch(s)
+	if match == nil {
+		return 0, nil, false
+	}
+	return r.kind, r.value(s, match), true
+}
+
+// recognizes the tokens matching expr as a whole, value gives the
+// normalized value from the submatches, nil keeps the token text
+func NewRegexpRecognizer(kind TokenKind, expr string, value func(s string, match []string) interface{}) (Recognizer, error) {
+	r, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		value = func(s string, match []string) interface{} { return s }
+	}
+	return &regexpRecognizer{kind, r, value}, nil
+}
+
+func mustRegexpRecognizer(kind TokenKind, expr string, value func(s string, match []string) interface{}) Recognizer {
+	r, err := NewRegexpRecognizer(kind, expr, value)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+var romanDigits = map[byte]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100, 'D': 500, 'M': 1000}
+
+func romanValue(s string) (n int) {
+	for i := 0; i < len(s); i++ {
+		d := romanDigits[s[i]]
+		if i+1 < len(s) && d < romanDigits[s[i+1]] {
+			n -= d
+		} else {
+			n += d
+		}
+	}
+	return
+}
+
+// french numbers as 10 000, 3,14, 1er or 12,5 %
+var numberRecognizer = RecognizerFunc(func(s string) (kind TokenKind, value interface{}, ok bool) {
+	n, number := ScanNumber(s)
+	if n == 0 || n != len(s) {
+		return 0, nil, false
+	}
+	return NUMBER, number, true
+})
+
+// XXIe, Ier but not Ind
+var romanRecognizer = mustRegexpRecognizer(ROMAN, "([MDCLXVI]+)(ème|ère|ᵉʳ|er|re|ᵉ|e)?", func(s string, match []string) interface{} {
+	return NumberValue{Value: float64(romanValue(match[1])), Ordinal: match[2] != ""}
+})
+
+var hexRecognizer = mustRegexpRecognizer(HEX, "(?:0x)?([0-9a-fA-F]+)", func(s string, match []string) interface{} {
+	n, err := strconv.ParseUint(match[1], 16, 64)
+	if err != nil {
+		return s
+	}
+	return n
+})
+
+var timeRecognizer = mustRegexpRecognizer(TIME, "([0-6]{1,2})(?:h|:)([0-6][0-9])", func(s string, match []string) interface{} {
+	return TimeValue{atoi(match[1]), atoi(match[2])}
+})
+
+// yyyy/mm/dd or the french dd/mm/yyyy
+var dateRecognizer = mustRegexpRecognizer(DATE, "([0-9]{2,4})/([0-9]{2})/([0-9]{2,4})", func(s string, match []string) interface{} {
+	if len(match[1]) == 4 {
+		return DateValue{atoi(match[1]), atoi(match[2]), atoi(match[3])}
+	}
+	return DateValue{atoi(match[3]), atoi(match[2]), atoi(match[1])}
+})
+
+var tempRecognizer = mustRegexpRecognizer(TEMP, "(-?[0-9]+)°(C|F|K)", func(s string, match []string) interface{} {
+	return TempValue{atoi(match[1]), match[2][0]}
+})
+
+var addressRecognizer = mustRegexpRecognizer(URL, `((http|https|ftp)://)?([0-9a-z_-]+\x2E)+(aero|asia|biz|cat|com|coop|edu|gov|info|int|jobs|mil|mobi|museum|name|net|org|pro|tel|travel|ac|ad|ae|af|ag|ai|al|am|an|ao|aq|ar|as|at|au|aw|ax|az|ba|bb|bd|be|bf|bg|bh|bi|bj|bm|bn|bo|br|bs|bt|bv|bw|by|bz|ca|cc|cd|cf|cg|ch|ci|ck|cl|cm|cn|co|cr|cu|cv|cx|cy|cz|cz|de|dj|dk|dm|do|dz|ec|ee|eg|er|es|et|eu|fi|fj|fk|fm|fo|fr|ga|gb|gd|ge|gf|gg|gh|gi|gl|gm|gn|gp|gq|gr|gs|gt|gu|gw|gy|hk|hm|hn|hr|ht|hu|id|ie|il|im|in|io|iq|ir|is|it|je|jm|jo|jp|ke|kg|kh|ki|km|kn|kp|kr|kw|ky|kz|la|lb|lc|li|lk|lr|ls|lt|lu|lv|ly|ma|mc|md|me|mg|mh|mk|ml|mn|mn|mo|mp|mr|ms|mt|mu|mv|mw|mx|my|mz|na|nc|ne|nf|ng|ni|nl|no|np|nr|nu|nz|nom|pa|pe|pf|pg|ph|pk|pl|pm|pn|pr|ps|pt|pw|py|qa|re|ra|rs|ru|rw|sa|sb|sc|sd|se|sg|sh|si|sj|sj|sk|sl|sm|sn|so|sr|st|su|sv|sy|sz|tc|td|tf|tg|th|tj|tk|tl|tm|tn|to|tp|tr|tt|tv|tw|tz|ua|ug|uk|us|uy|uz|va|vc|ve|vg|vi|vn|vu|wf|ws|ye|yt|yu|za|zm|zw|arpa)(:[0-9]+)?(/[[0-9a-z\/\?\=\#\(\)_\-\.]+)?`, nil)
+
+// twitter handles and web addresses
+var urlRecognizer = RecognizerFunc(func(s string) (kind TokenKind, value interface{}, ok bool) {
+	if strings.HasPrefix(s, "@") {
+		return URL, s, true
+	}
+	return addressRecognizer.Recognize(s)
+})
+
+type recognizerEntry struct {
+	name       string
+	priority   int
+	recognizer Recognizer
+}
+
+// recognizers tried by decreasing priority on tokens without word, the
+// first match gives the kind of the token, a registry is not safe for
+// use during a tokenization
+type Recognizers struct {
+	entries []recognizerEntry
+}
+
+// the recognizers of a new TokenizeContext
+func DefaultRecognizers() *Recognizers {
+	recognizers := new(Recognizers)
+	recognizers.Add("number", 70, numberRecognizer)
+	recognizers.Add("roman", 60, romanRecognizer)
+	recognizers.Add("hex", 50, hexRecognizer)
+	recognizers.Add("time", 40, timeRecognizer)
+	recognizers.Add("date", 30, dateRecognizer)
+	recognizers.Add("temp", 20, tempRecognizer)
+	recognizers.Add("url", 10, urlRecognizer)
+	return recognizers
+}
+
+// adds or replaces the recognizer called name, recognizers of the same
+// priority are tried in the order they were added
+func (recognizers *Recognizers) Add(name string, priority int, recognizer Recognizer) {
+	recognizers.Remove(name)
+	recognizers.entries = append(recognizers.entries, recognizerEntry{name, priority, recognizer})
+	sort.SliceStable(recognizers.entries, func(i, j int) bool {
+		return recognizers.entries[i].priority > recognizers.entries[j].priority
+	})
+}
+
+func (recognizers *Recognizers) Remove(name string) bool {
+	for i, entry := range recognizers.entries {
+		if entry.name == name {
+			recognizers.entries = append(recognizers.entries[:i], recognizers.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (recognizers *Recognizers) Get(name string) Recognizer {
+	for _, entry := range recognizers.entries {
+		if entry.name == name {
+			return entry.recognizer
+		}
+	}
+	return nil
+}
+
+// names by decreasing priority
+func (recognizers *Recognizers) Names() (names []string) {
+	for _, entry := range recognizers.entries {
+		names = append(names, entry.name)
+	}
+	return
+}
+
+func (recognizers *Recognizers) Recognize(s string) (kind TokenKind, value interface{}, ok bool) {
+	for _, entry := range recognizers.entries {
+		kind, value, ok = entry.recognizer.Recognize(s)
+		if ok {
+			return
+		}
+	}
+	return 0, nil, false
+}
@@ -0,0 +1,98 @@
+package words
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecognizers(t *testing.T) {
+	context := NewTestContext(GetLemmaDictionary())
+	tests := []struct {
+		s     string
+		kind  TokenKind
+		value interface{}
+	}{
+		{"123", NUMBER, NumberValue{Value: 123}},
+		{"3e", NUMBER, NumberValue{Value: 3, Ordinal: true}},
+		{"XVIII", ROMAN, NumberValue{Value: 18}},
+		{"XIXe", ROMAN, NumberValue{Value: 19, Ordinal: true}},
+		{"0xff", HEX, uint64(255)},
+		{"12h20", TIME, TimeValue{12, 20}},
+		{"12:20", TIME, TimeValue{12, 20}},
+		{"25/12/2012", DATE, DateValue{2012, 12, 25}},
+		{"2012/12/25", DATE, DateValue{2012, 12, 25}},
+		{"-8°C", TEMP, TempValue{-8, 'C'}},
+		{"@desjare", URL, "@desjare"},
+		{"lapresse.ca", URL, "lapresse.ca"},
+	}
+	for _, test := range tests {
+		kind, value, ok := TokenizeRecognize(test.s, context)
+		if !ok || kind != test.kind || value != test.value {
+			t.Errorf("%s recognized as %s %v", test.s, kind, value)
+		}
+	}
+	for _, s := range []string{"lapresse", "12/12", "abcz"} {
+		if kind, _, ok := TokenizeRecognize(s, context); ok {
+			t.Errorf("%s recognized as %s", s, kind)
+		}
+	}
+	if TokenizeIsTime("12", context) || !TokenizeIsNumber("XVIII", context) || !TokenizeIsTemp("8°C", context) {
+		t.Errorf("bad kind tests")
+	}
+}
+
+const (
+	REFERENCE = USERKIND
+	YEAR      = USERKIND + 1
+)
+
+func TestAddRecognizer(t *testing.T) {
+	context := NewTestContext(GetLemmaDictionary())
+	reference := RecognizerFunc(func(s string) (kind TokenKind, value interface{}, ok bool) {
+		if len(s) > 3 && strings.HasPrefix(s, "REF") {
+			return REFERENCE, s[3:], true
+		}
+		return 0, nil, false
+	})
+	year, err := NewRegexpRecognizer(YEAR, "[12][0-9]{3}", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	context.Recognizers().Add("reference", 5, reference)
+	// years before numbers
+	context.Recognizers().Add("year", 80, year)
+
+	names := strings.Join(context.Recognizers().Names(), " ")
+	if names != "year number roman hex time date temp url reference" {
+		t.Errorf("bad recognizer order %s", names)
+	}
+
+	text := "chiens REF42 1984 12"
+	tokens := Tokenize(text, context, true)
+	if len(tokens) != 7 {
+		t.Fatalf("bad token count %d", len(tokens))
+	}
+	if tokens[2].Kind != REFERENCE || tokens[2].Value != "42" || !tokens[2].IsValid() {
+		t.Errorf("reference not recognized %s", tokens[2].String())
+	}
+	if tokens[4].Kind != YEAR || tokens[4].Value != "1984" {
+		t.Errorf("year not recognized %s", tokens[4].String())
+	}
+	if tokens[6].Kind != NUMBER || tokens[6].Value != (NumberValue{Value: 12}) {
+		t.Errorf("number not recognized %s", tokens[6].String())
+	}
+	if tokens[0].Kind != 0 || tokens[1].Kind != 0 || tokens[1].IsValid() {
+		t.Errorf("words have a kind")
+	}
+
+	if !context.Recognizers().Remove("year") || context.Recognizers().Get("year") != nil {
+		t.Errorf("year not removed")
+	}
+	tokens = Tokenize(text, context, true)
+	if tokens[4].Kind != NUMBER {
+		t.Errorf("removed recognizer used %s", tokens[4].String())
+	}
+	if DefaultRecognizers().Get("reference") != nil {
+		t.Errorf("default recognizers changed")
+	}
+}
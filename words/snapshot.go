@@ -0,0 +1,212 @@
+package words
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// an immutable view of a lexicon, nothing reachable from a snapshot
+// is modified once it is published
+type Snapshot struct {
+	Lexicon    Lexicon
+	Dict       *Dictionary // nil for a compact dictionary
+	Generation uint64      // incremented by every publication
+	Loaded     time.Time   // when the base lexicon was loaded
+}
+
+// publishes snapshots of a base lexicon and its user layers, readers
+// get the current snapshot without locking while writers build a new
+// one and swap it in
+//
+// a Dictionary is not safe for concurrent writes, words added at
+// runtime go through AddWord, Suppress or Update which publish them
+// in new runtime layers
+type LexiconStore struct {
+	current  atomic.Value // *Snapshot
+	writer   sync.Mutex
+	reloader sync.Mutex                           // one Reload at a time
+	load     func() (Lexicon, *Dictionary, error) // used by Reload
+
+	base   Lexicon
+	dict   *Dictionary
+	loaded time.Time
+	layers []*UserLayer
+}
+
+var ErrNoReload = errors.New("lexicon store cannot reload")
+
+var ErrCompactRewritten = errors.New("compact dictionary rewritten in place instead of renamed")
+
+// name of the layer holding AddWord and Suppress changes
+const RuntimeLayer = "runtime"
+
+// store over a lexicon already loaded, dict may be nil, the store
+// cannot Reload
+func NewLexiconStore(lexicon Lexicon, dict *Dictionary) *LexiconStore {
+	store := LexiconStore{}
+	store.base = lexicon
+	store.dict = dict
+	store.loaded = time.Now()
+	store.publish()
+	return &store
+}
+
+// store calling load for the lexicon and on Reload, load is never
+// called concurrently
+func NewLoaderStore(load func() (Lexicon, *Dictionary, error)) (*LexiconStore, error) {
+	lexicon, dict, err := load()
+	if err != nil {
+		return nil, err
+	}
+	store := NewLexiconStore(lexicon, dict)
+	store.load = load
+	return store, nil
+}
+
+// store loading the lexicon of m, Reload reads m again
+func NewManifestStore(m *Manifest) (*LexiconStore, error) {
+	return NewLoaderStore(m.Lexicon)
+}
+
+// store over a compact dictionary, Reload maps path again
+//
+// readers keep the mapped file until the snapshots using it are
+// collected, so path must be replaced by rename as WriteCompact does:
+// a file rewritten in place changes under them, Reload refuses it
+func NewCompactStore(path string) (*LexiconStore, error) {
+	var mapped os.FileInfo
+	return NewLoaderStore(func() (Lexicon, *Dictionary, error) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if mapped != nil && os.SameFile(mapped, info) &&
+			(info.Size() != mapped.Size() || !info.ModTime().Equal(mapped.ModTime())) {
+			return nil, nil, fmt.Errorf("%s: %w", path, ErrCompactRewritten)
+		}
+		compact, err := ReadCompact(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		mapped = info
+		return compact, nil, nil
+	})
+}
+
+func (store *LexiconStore) Snapshot() *Snapshot {
+	return store.current.Load().(*Snapshot)
+}
+
+// must be called with the writer lock held or before the store is shared
+func (store *LexiconStore) publish() *Snapshot {
+	snapshot := Snapshot{}
+	snapshot.Dict = store.dict
+	snapshot.Loaded = store.loaded
+	snapshot.Lexicon = store.base
+	if len(store.layers) > 0 {
+		snapshot.Lexicon = &LayeredDictionary{store.base, append([]*UserLayer{}, store.layers...)}
+	}
+	if current, ok := store.current.Load().(*Snapshot); ok {
+		snapshot.Generation = current.Generation + 1
+	}
+	store.current.Store(&snapshot)
+	return &snapshot
+}
+
+// replaces the base lexicon, user layers are kept over it
+func (store *LexiconStore) SetBase(lexicon Lexicon, dict *Dictionary) *Snapshot {
+	store.writer.Lock()
+	defer store.writer.Unlock()
+
+	store.setBase(lexicon, dict)
+	return store.publish()
+}
+
+func (store *LexiconStore) setBase(lexicon Lexicon, dict *Dictionary) {
+	// readers may still use a replaced compact dictionary, it is
+	// unmapped once nothing references it, its file must have been
+	// renamed over and not rewritten, see NewCompactStore
+	if compact, ok := store.base.(*CompactDictionary); ok && compact != lexicon {
+		runtime.SetFinalizer(compact, (*CompactDictionary).Close)
+	}
+	store.base = lexicon
+	store.dict = dict
+	store.loaded = time.Now()
+}
+
+// loads the lexicon again, for example after lm.bin was rebuilt, and
+// publishes it with the current user layers
+func (store *LexiconStore) Reload() (*Snapshot, error) {
+	if store.load == nil {
+		return nil, ErrNoReload
+	}
+
+	// load outside of the writer lock, readers and writers keep going,
+	// reloads are serialized so that the last load is published last
+	store.reloader.Lock()
+	defer store.reloader.Unlock()
+	lexicon, dict, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+
+	store.writer.Lock()
+	defer store.writer.Unlock()
+
+	store.setBase(lexicon, dict)
+	return store.publish(), nil
+}
+
+// adds layer over the current layers, the layer must not be modified
+// once added
+func (store *LexiconStore) AddLayer(layer *UserLayer) *Snapshot {
+	store.writer.Lock()
+	defer store.writer.Unlock()
+
+	store.layers = append(store.layers, layer)
+	return store.publish()
+}
+
+// applies update to a new runtime layer and publishes it, several
+// changes made by one update are published together
+//
+// the runtime layers above the others are merged while the lower one
+// has no more changes than the upper one, as the digits of a binary
+// counter, so n updates copy O(n log n) changes and a lookup goes
+// through O(log n) runtime layers
+func (store *LexiconStore) Update(update func(layer *UserLayer)) *Snapshot {
+	store.writer.Lock()
+	defer store.writer.Unlock()
+
+	layer := NewUserLayer(RuntimeLayer, "")
+	update(layer)
+
+	layers := append([]*UserLayer{}, store.layers...)
+	for n := len(layers); n > 0; n = len(layers) {
+		lower := layers[n-1]
+		if lower.Name != RuntimeLayer || lower.changes > layer.changes {
+			break
+		}
+		layer = mergeLayers(RuntimeLayer, lower, layer)
+		layers = layers[:n-1]
+	}
+	store.layers = append(layers, layer)
+	return store.publish()
+}
+
+func (store *LexiconStore) AddWord(form string, variants ...WordVariant) *Snapshot {
+	return store.Update(func(layer *UserLayer) {
+		layer.AddWord(form, variants...)
+	})
+}
+
+func (store *LexiconStore) Suppress(form string, variants ...WordVariant) *Snapshot {
+	return store.Update(func(layer *UserLayer) {
+		layer.Suppress(form, variants...)
+	})
+}
@@ -0,0 +1,251 @@
+package words
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func GetTestStore() *LexiconStore {
+	dict := GetLemmaDictionary()
+	dict.AddBuiltin()
+	dict.AddWordWithTag(" ", SPACE)
+	return NewLexiconStore(dict, dict)
+}
+
+func TestStoreAddWord(t *testing.T) {
+	store := GetTestStore()
+	before := store.Snapshot()
+
+	after := store.AddWord("babble", WordVariant{Tag: NOUN, Language: FRENCH, Lemma: "babble"})
+	if after.Generation != before.Generation+1 || store.Snapshot() != after {
+		t.Errorf("snapshot not published")
+	}
+	if word, _ := before.Lexicon.FindWord("babble"); word != nil {
+		t.Errorf("published snapshot modified")
+	}
+	if word, _ := after.Lexicon.FindWord("babble"); word == nil {
+		t.Errorf("babble not added")
+	}
+
+	suppressed := store.Suppress("chiens")
+	if word, _ := after.Lexicon.FindWord("chiens"); word == nil {
+		t.Errorf("published layer modified")
+	}
+	if word, _ := suppressed.Lexicon.FindWord("chiens"); word != nil {
+		t.Errorf("chiens not suppressed")
+	}
+	if word, _ := suppressed.Lexicon.FindWord("babble"); word == nil {
+		t.Errorf("runtime layer not copied")
+	}
+	if suppressed.Dict != after.Dict {
+		t.Errorf("dictionary changed by runtime words")
+	}
+}
+
+func TestStoreReload(t *testing.T) {
+	_, err := GetTestStore().Reload()
+	if !errors.Is(err, ErrNoReload) {
+		t.Errorf("store without loader reloaded")
+	}
+
+	dict := GetLemmaDictionary()
+	err = dict.WriteBinary("test.bin")
+	if err != nil {
+		t.Fatalf("cannot write binary %s", err)
+	}
+	defer os.Remove("test.bin")
+
+	m := Manifest{}
+	m.Binary = "test.bin"
+	store, err := NewManifestStore(&m)
+	if err != nil {
+		t.Fatalf("cannot load manifest %s", err)
+	}
+	store.AddWord("babble", WordVariant{Tag: NOUN, Language: FRENCH})
+	if word, _ := store.Snapshot().Lexicon.FindWord("loup"); word != nil {
+		t.Errorf("loup before rebuild")
+	}
+
+	dict.AddWord("loup", &Word{})
+	err = dict.WriteBinary("test.bin")
+	if err != nil {
+		t.Fatalf("cannot write binary %s", err)
+	}
+	snapshot, err := store.Reload()
+	if err != nil {
+		t.Fatalf("cannot reload %s", err)
+	}
+	if word, _ := snapshot.Lexicon.FindWord("loup"); word == nil {
+		t.Errorf("rebuilt binary not loaded")
+	}
+	if word, _ := snapshot.Lexicon.FindWord("babble"); word == nil {
+		t.Errorf("runtime words lost by reload")
+	}
+	if snapshot.Dict == nil || snapshot.Dict.Header == nil {
+		t.Errorf("reloaded dictionary not set")
+	}
+}
+
+func TestStoreConcurrent(t *testing.T) {
+	store := GetTestStore()
+	context, err := TokenizeNewStoreContext(store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := "chien chiens"
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tokens := Tokenize(text, context, false)
+				if len(tokens) != 3 || tokens[0].Word == nil {
+					t.Errorf("bad tokens while writing")
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 100; j++ {
+		store.AddWord("babble", WordVariant{Tag: NOUN, Language: FRENCH, Number: byte(j%2) + 1})
+	}
+	wg.Wait()
+
+	pinned := context.Pin()
+	store.Suppress("chien")
+	if word, _ := TokenizeFindWord("chien", pinned); word == nil {
+		t.Errorf("pinned context sees new snapshot")
+	}
+	if word, _ := TokenizeFindWord("chien", context); word != nil {
+		t.Errorf("context does not see new snapshot")
+	}
+}
+
+func TestStoreConcurrentReload(t *testing.T) {
+	// loaders keep state as NewCompactStore does, -race checks they are
+	// not called concurrently
+	loads := 0
+	store, err := NewLoaderStore(func() (Lexicon, *Dictionary, error) {
+		loads++
+		dict := GetLemmaDictionary()
+		dict.AddWord(fmt.Sprintf("load%d", loads), &Word{})
+		// older loads are slower, published after a newer one they
+		// would hide it
+		time.Sleep(time.Duration(3-loads%3) * time.Millisecond)
+		return dict, dict, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_, err := store.Reload()
+				if err != nil {
+					t.Errorf("cannot reload %s", err)
+				}
+				store.AddWord("babble", WordVariant{Tag: NOUN, Language: FRENCH})
+			}
+		}()
+	}
+	wg.Wait()
+
+	snapshot := store.Snapshot()
+	last := fmt.Sprintf("load%d", loads)
+	if word, _ := snapshot.Lexicon.FindWord(last); word == nil {
+		t.Errorf("%s not published last", last)
+	}
+	if word, _ := snapshot.Lexicon.FindWord("babble"); word == nil {
+		t.Errorf("runtime words lost by reloads")
+	}
+}
+
+func TestStoreRuntimeLayers(t *testing.T) {
+	store := GetTestStore()
+	reference := LayeredDictionary{}
+	reference.Base = store.Snapshot().Lexicon
+
+	forms := []string{"chien", "chiens", "babble", "chat", "loup"}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 300; i++ {
+		form := forms[random.Intn(len(forms))]
+		variant := WordVariant{Tag: NOUN, Language: FRENCH, Number: byte(random.Intn(2)) + 1, Lemma: form}
+		layer := NewUserLayer(RuntimeLayer, "")
+		switch random.Intn(3) {
+		case 0:
+			store.Suppress(form)
+			layer.Suppress(form)
+		case 1:
+			store.Suppress(form, variant)
+			layer.Suppress(form, variant)
+		default:
+			store.AddWord(form, variant)
+			layer.AddWord(form, variant)
+		}
+		reference.AddLayer(layer)
+
+		lexicon := store.Snapshot().Lexicon
+		for _, form := range forms {
+			word, _ := lexicon.FindWord(form)
+			expected, _ := reference.FindWord(form)
+			if (word == nil) != (expected == nil) || word != nil && word.Description() != expected.Description() {
+				t.Fatalf("update %d: %s is %v, expected %v", i, form, word, expected)
+			}
+		}
+	}
+
+	layers := store.Snapshot().Lexicon.(*LayeredDictionary).Layers
+	if len(layers) > 9 {
+		t.Errorf("%d runtime layers for 300 updates", len(layers))
+	}
+}
+
+func TestStoreCompactRewritten(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.dawg")
+	dict := GetLemmaDictionary()
+	err := dict.WriteCompact(path)
+	if err != nil {
+		t.Fatalf("cannot write compact %s", err)
+	}
+	store, err := NewCompactStore(path)
+	if err != nil {
+		t.Fatalf("cannot load compact %s", err)
+	}
+
+	// appending keeps the mapped pages valid
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte("junk"))
+	f.Close()
+	_, err = store.Reload()
+	if !errors.Is(err, ErrCompactRewritten) {
+		t.Errorf("in place rewrite not refused: %v", err)
+	}
+
+	dict.AddWord("loup", &Word{})
+	err = dict.WriteCompact(path)
+	if err != nil {
+		t.Fatalf("cannot rewrite compact %s", err)
+	}
+	snapshot, err := store.Reload()
+	if err != nil {
+		t.Fatalf("cannot reload renamed compact %s", err)
+	}
+	if word, _ := snapshot.Lexicon.FindWord("loup"); word == nil {
+		t.Errorf("renamed compact not loaded")
+	}
+}
@@ -0,0 +1,177 @@
+package words
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+type TagLanguage struct {
+	Tag      byte
+	Language byte
+}
+
+// statistics of a lexicon
+type Stats struct {
+	Words      int
+	Variants   int
+	MaxWordLen int
+	MaxTokens  int
+
+	Tags      map[TagLanguage]int // variants by tag and language
+	Ambiguity map[int]int         // words by number of variants
+
+	// forms without variant or with a variant without tag, the ...
+	// word added by ReadXML
+	Untagged []string
+}
+
+func NewStats(lexicon Lexicon) *Stats {
+	stats := Stats{}
+	stats.Tags = make(map[TagLanguage]int)
+	stats.Ambiguity = make(map[int]int)
+	stats.MaxWordLen, stats.MaxTokens = lexicon.Limits()
+
+	lexicon.Each(WalkOptions{}, func(w *Word) bool {
+		stats.Words++
+		stats.Variants += len(w.Variants)
+		stats.Ambiguity[len(w.Variants)]++
+
+		untagged := len(w.Variants) == 0
+		for _, v := range w.Variants {
+			stats.Tags[TagLanguage{v.Tag, v.Language}]++
+			untagged = untagged || v.Tag == 0
+		}
+		if untagged {
+			stats.Untagged = append(stats.Untagged, w.String())
+		}
+		return true
+	})
+	return &stats
+}
+
+func (stats *Stats) Write(w io.Writer) {
+	fmt.Fprintf(w, "words %d\n", stats.Words)
+	fmt.Fprintf(w, "variants %d\n", stats.Variants)
+	fmt.Fprintf(w, "max word len %d\n", stats.MaxWordLen)
+	fmt.Fprintf(w, "max tokens %d\n", stats.MaxTokens)
+
+	fmt.Fprintf(w, "variants by tag and language\n")
+	var tags []TagLanguage
+	for tag := range stats.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Tag != tags[j].Tag {
+			return tags[i].Tag < tags[j].Tag
+		}
+		return tags[i].Language < tags[j].Language
+	})
+	for _, tag := range tags {
+		language := "-"
+		if name := findFeatureName(languageNames, tag.Language); name != nil {
+			language = name.code
+		}
+		fmt.Fprintf(w, "\t%s\t%s\t%d\n", TagCode(tag.Tag), language, stats.Tags[tag])
+	}
+
+	fmt.Fprintf(w, "words by number of variants\n")
+	var counts []int
+	for count := range stats.Ambiguity {
+		counts = append(counts, count)
+	}
+	sort.Ints(counts)
+	for _, count := range counts {
+		fmt.Fprintf(w, "\t%d\t%d\n", count, stats.Ambiguity[count])
+	}
+
+	fmt.Fprintf(w, "untagged forms %d\n", len(stats.Untagged))
+	for _, form := range stats.Untagged {
+		fmt.Fprintf(w, "\t%q\n", form)
+	}
+}
+
+// forms added, removed and whose variants changed from a lexicon to
+// another, in rune order
+type Diff struct {
+	Added   []*Word
+	Removed []*Word
+	Changed []VariantChange
+}
+
+// variants of a form only in the old or only in the new lexicon
+type VariantChange struct {
+	Form    string
+	Removed []WordVariant
+	Added   []WordVariant
+}
+
+func (word *Word) containsVariant(variant *WordVariant) bool {
+	for i := range word.Variants {
+		if word.Variants[i].Equals(variant) {
+			return true
+		}
+	}
+	return false
+}
+
+// variants of word missing from other
+func (word *Word) missingVariants(other *Word) (variants []WordVariant) {
+	for i := range word.Variants {
+		if !other.containsVariant(&word.Variants[i]) {
+			variants = append(variants, word.Variants[i])
+		}
+	}
+	return
+}
+
+func NewDiff(from Lexicon, to Lexicon) *Diff {
+	diff := Diff{}
+	from.Each(WalkOptions{}, func(w *Word) bool {
+		found, _ := to.FindWord(w.String())
+		if found == nil {
+			diff.Removed = append(diff.Removed, w)
+			return true
+		}
+		change := VariantChange{Form: w.String()}
+		change.Removed = w.missingVariants(found)
+		change.Added = found.missingVariants(w)
+		if len(change.Removed) > 0 || len(change.Added) > 0 {
+			diff.Changed = append(diff.Changed, change)
+		}
+		return true
+	})
+	to.Each(WalkOptions{}, func(w *Word) bool {
+		found, _ := from.FindWord(w.String())
+		if found == nil {
+			diff.Added = append(diff.Added, w)
+		}
+		return true
+	})
+	return &diff
+}
+
+func (diff *Diff) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// + and - lines of the forms with their variants, then the changed
+// forms with their + and - variants
+func (diff *Diff) Write(w io.Writer) {
+	fmt.Fprintf(w, "added %d removed %d changed %d\n", len(diff.Added), len(diff.Removed), len(diff.Changed))
+	for _, word := range diff.Added {
+		fmt.Fprintf(w, "+ %s\n", word.Description())
+	}
+	for _, word := range diff.Removed {
+		fmt.Fprintf(w, "- %s\n", word.Description())
+	}
+	for _, change := range diff.Changed {
+		fmt.Fprintf(w, "~ %s\n", change.Form)
+		for _, v := range change.Removed {
+			fmt.Fprintf(w, "\t- %s\n", v.String())
+		}
+		for _, v := range change.Added {
+			fmt.Fprintf(w, "\t+ %s\n", v.String())
+		}
+	}
+}
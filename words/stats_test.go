@@ -0,0 +1,83 @@
+package words
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	dict := GetLemmaDictionary()
+	dict.AddWord("...", new(Word))
+	dict.AddWordWithTag("/", SLASH)
+	word := Word{}
+	word.Variants = append(word.Variants, WordVariant{Tag: VERB, Language: FRENCH, Tense: IND, Person: 3, Number: SINGULAR, Lemma: "chienner"})
+	dict.AddWord("chienne", &word)
+
+	stats := NewStats(dict)
+	if stats.Words != 6 || stats.Variants != 6 {
+		t.Errorf("bad counts %d %d", stats.Words, stats.Variants)
+	}
+	if stats.Tags[TagLanguage{NOUN, FRENCH}] != 4 || stats.Tags[TagLanguage{VERB, FRENCH}] != 1 || stats.Tags[TagLanguage{SLASH, 0}] != 1 {
+		t.Errorf("bad tags %v", stats.Tags)
+	}
+	if len(stats.Ambiguity) != 3 || stats.Ambiguity[0] != 1 || stats.Ambiguity[1] != 4 || stats.Ambiguity[2] != 1 {
+		t.Errorf("bad ambiguity %v", stats.Ambiguity)
+	}
+	if len(stats.Untagged) != 1 || stats.Untagged[0] != "..." {
+		t.Errorf("bad untagged %v", stats.Untagged)
+	}
+	if stats.MaxWordLen != 8 {
+		t.Errorf("bad max word len %d", stats.MaxWordLen)
+	}
+
+	var buf bytes.Buffer
+	stats.Write(&buf)
+	if !strings.Contains(buf.String(), "\tN\tfr\t4\n") || !strings.Contains(buf.String(), "untagged forms 1\n\t\"...\"\n") {
+		t.Errorf("bad stats\n%s", buf.String())
+	}
+
+	compact := NewStats(GetCompactDictionary(t, dict))
+	if compact.Words != stats.Words || compact.Variants != stats.Variants || len(compact.Untagged) != 1 {
+		t.Errorf("bad compact stats %+v", compact)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	from := GetLemmaDictionary()
+	to := GetLemmaDictionary()
+
+	if !NewDiff(from, to).Empty() {
+		t.Errorf("same dictionaries differ")
+	}
+
+	word := Word{}
+	word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Gender: MALE, Number: SINGULAR, Lemma: "chat"})
+	to.AddWord("chat", &word)
+	from.AddWordWithTag("/", SLASH)
+	// chiens becomes singular
+	found, _ := to.FindWord("chiens")
+	found.Variants[0].Number = SINGULAR
+
+	diff := NewDiff(from, to)
+	if len(diff.Added) != 1 || diff.Added[0].String() != "chat" {
+		t.Errorf("bad added %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0].String() != "/" {
+		t.Errorf("bad removed %v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Form != "chiens" {
+		t.Fatalf("bad changed %v", diff.Changed)
+	}
+	change := diff.Changed[0]
+	if len(change.Removed) != 1 || change.Removed[0].Number != PLURAL || len(change.Added) != 1 || change.Added[0].Number != SINGULAR {
+		t.Errorf("bad change %+v", change)
+	}
+
+	var buf bytes.Buffer
+	diff.Write(&buf)
+	expected := "added 1 removed 1 changed 1\n+ [chat[chat.N+fr:ms]]\n- [/[SLASH]]\n~ chiens\n\t- chien.N+fr:mp\n\t+ chien.N+fr:ms\n"
+	if buf.String() != expected {
+		t.Errorf("bad diff\n%s", buf.String())
+	}
+}
@@ -0,0 +1,274 @@
+package words
+
+import (
+	"errors"
+	"io"
+	"strings"
+)
+
+// bytes read at once by the streams
+const streamReadSize = 64 * 1024
+
+// bytes buffered at most without finding where to cut a segment
+const streamMaxSegment = 16 * streamReadSize
+
+// returned by TokenStream.Err when streamMaxSegment bytes hold no
+// whitespace across which no token nor compound word goes on
+var ErrSegmentTooLong = errors.New("token stream: segment too long")
+
+// tokenizes a reader with the results of Tokenize on the whole text,
+// the text is tokenized by segments ending with a whitespace across
+// which no token nor compound word can be found, Pos are absolute byte
+// offsets
+//
+// rather than cut where the tokens could differ from Tokenize, the
+// stream stops with ErrSegmentTooLong after streamMaxSegment bytes
+// without such a whitespace
+type TokenStream struct {
+	reader   io.Reader
+	context  *TokenizeContext
+	compound bool
+	size     int // bytes read at once
+	limit    int // bytes buffered before ErrSegmentTooLong
+
+	text   []byte // read and not tokenized, from offset
+	offset int
+	eof    bool
+	err    error
+
+	// tokens of the current segment
+	segment       string
+	segmentOffset int
+	tokens        []Token
+	token         Token
+}
+
+// the lexicon is pinned for the whole stream as for one Tokenize
+func NewTokenStream(r io.Reader, context *TokenizeContext, compound bool) *TokenStream {
+	stream := TokenStream{}
+	stream.reader = r
+	stream.size = streamReadSize
+	stream.limit = streamMaxSegment
+	stream.context = context.Pin()
+	stream.compound = compound
+	return &stream
+}
+
+// reads what the reader has, up to size bytes
+func (stream *TokenStream) read() {
+	n := len(stream.text)
+	if cap(stream.text)-n < stream.size {
+		text := make([]byte, n, 2*n+stream.size)
+		copy(text, stream.text)
+		stream.text = text
+	}
+	read, err := stream.reader.Read(stream.text[n : n+stream.size])
+	stream.text = stream.text[:n+read]
+	if err == io.EOF {
+		stream.eof = true
+	} else if err != nil {
+		stream.err = err
+	}
+}
+
+// false when a compound word of the lexicon may go on after the end of
+// content, the whitespace ending basic
+func (stream *TokenStream) safeCut(content string, basic []Token) bool {
+	if !stream.compound {
+		return true
+	}
+	_, maxTokens := stream.context.Snapshot().Lexicon.Limits()
+	for k := len(basic) - 2; k >= 0 && k >= len(basic)-1-maxTokens; k-- {
+		searchPath := true
+		TokenizeBuildToken(content, &searchPath, basic[k].Pos[0], len(content), stream.context)
+		if searchPath {
+			return false
+		}
+	}
+	return true
+}
+
+// true when a token cannot go on after the whitespace ending s, numbers
+// as 10 000 or 12,5 % go on after a space following a digit
+func streamCutAfter(s string) bool {
+	n := len(s)
+	if n == 0 || !strings.ContainsRune(" \t\r\n", rune(s[n-1])) {
+		return false
+	}
+	return s[n-1] == '\n' || n == 1 || s[n-2] < '0' || s[n-2] > '9'
+}
+
+// length of the next segment, after the last whitespace no token nor
+// compound word goes on, with its tokens, 0 when more text is needed
+func (stream *TokenStream) cut() (n int, basic []Token) {
+	text := stream.text
+	last := len(text)
+	for last > 0 && !strings.ContainsRune(" \t\r\n", rune(text[last-1])) {
+		last--
+	}
+
+	if last > 0 {
+		content := string(text[:last])
+		basic = Tokenize(content, stream.context, false)
+		for k := len(basic) - 1; k >= 0; k-- {
+			end := basic[k].Pos[1]
+			if !streamCutAfter(content[:end]) {
+				continue
+			}
+			if stream.safeCut(content[:end], basic[:k+1]) {
+				return end, basic[:k+1]
+			}
+		}
+	}
+	return 0, nil
+}
+
+// tokens of the next segment with absolute Pos, the segment text and
+// its offset, io.EOF after the last segment
+func (stream *TokenStream) next() (tokens []Token, segment string, offset int, err error) {
+	for {
+		if stream.err != nil {
+			return nil, "", 0, stream.err
+		}
+		if stream.eof && len(stream.text) == 0 {
+			return nil, "", 0, io.EOF
+		}
+
+		cut := len(stream.text)
+		if !stream.eof {
+			cut, tokens = stream.cut()
+			if cut == 0 && len(stream.text) >= stream.limit {
+				stream.err = ErrSegmentTooLong
+				continue
+			}
+			if cut == 0 {
+				stream.read()
+				continue
+			}
+		}
+		segment = string(stream.text[:cut])
+
+		if tokens == nil {
+			tokens = Tokenize(segment, stream.context, false)
+		}
+		if stream.compound {
+			tokens = TokenizeCompoundToken(segment, tokens, stream.context)
+		}
+
+		offset = stream.offset
+		for i := range tokens {
+			tokens[i].Pos = []int{tokens[i].Pos[0] + offset, tokens[i].Pos[1] + offset}
+		}
+		stream.text = stream.text[cut:]
+		stream.offset += cut
+		return tokens, segment, offset, nil
+	}
+}
+
+// advances to the next token, false at the end of the text or on error
+func (stream *TokenStream) Scan() bool {
+	for len(stream.tokens) == 0 {
+		tokens, segment, offset, err := stream.next()
+		if err != nil {
+			return false
+		}
+		stream.tokens = tokens
+		stream.segment = segment
+		stream.segmentOffset = offset
+	}
+	stream.token = stream.tokens[0]
+	stream.tokens = stream.tokens[1:]
+	return true
+}
+
+func (stream *TokenStream) Token() Token {
+	return stream.token
+}
+
+// text of the current token
+func (stream *TokenStream) Text() string {
+	return stream.segment[stream.token.Pos[0]-stream.segmentOffset : stream.token.Pos[1]-stream.segmentOffset]
+}
+
+// the read error, nil at the end of the text
+func (stream *TokenStream) Err() error {
+	return stream.err
+}
+
+// splits a reader in the sentences of TokenizeSentence on the whole
+// text, a sentence is complete once the tokens deciding where it ends
+// are read, at most sentenceParenTokens tokens after an opening
+// parenthesis
+type SentenceStream struct {
+	tokens   *TokenStream
+	splitter sentenceSplitter
+	done     bool
+
+	text       []byte // of the tokens of the splitter, from textOffset
+	textOffset int
+
+	sentences []TokenSentence
+	sentence  TokenSentence
+}
+
+func NewSentenceStream(r io.Reader, context *TokenizeContext) *SentenceStream {
+	stream := SentenceStream{}
+	stream.tokens = NewTokenStream(r, context, true)
+	return &stream
+}
+
+// advances to the next sentence, false at the end of the text or on
+// error
+func (stream *SentenceStream) Scan() bool {
+	for len(stream.sentences) == 0 {
+		if stream.done {
+			return false
+		}
+		tokens, segment, offset, err := stream.tokens.next()
+		if err != nil {
+			if err != io.EOF {
+				return false
+			}
+			stream.sentences = stream.splitter.split(true)
+			stream.done = true
+			continue
+		}
+
+		if len(stream.text) == 0 {
+			stream.textOffset = offset
+		}
+		stream.text = append(stream.text, segment...)
+		stream.splitter.add(tokens)
+		stream.sentences = stream.splitter.split(false)
+	}
+	stream.sentence = stream.sentences[0]
+	stream.sentences = stream.sentences[1:]
+
+	// keep the text of the sentence and of the tokens still to split
+	if len(stream.splitter.tokens) > 0 && !stream.done {
+		start := stream.splitter.tokens[0].Pos[0]
+		if first := stream.sentence.Tokens[0].Pos[0]; first < start {
+			start = first
+		}
+		stream.text = stream.text[start-stream.textOffset:]
+		stream.textOffset = start
+	}
+	return true
+}
+
+func (stream *SentenceStream) Sentence() TokenSentence {
+	return stream.sentence
+}
+
+// text of the current sentence
+func (stream *SentenceStream) Text() string {
+	tokens := stream.sentence.Tokens
+	if len(tokens) == 0 {
+		return ""
+	}
+	return string(stream.text[tokens[0].Pos[0]-stream.textOffset : tokens[len(tokens)-1].Pos[1]-stream.textOffset])
+}
+
+func (stream *SentenceStream) Err() error {
+	return stream.tokens.Err()
+}
@@ -0,0 +1,230 @@
+package words
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+func GetStreamDictionary() *Dictionary {
+	dict := GetElisionDictionary()
+	dict.AddWordWithTag(".", DOT)
+	dict.AddWordWithTag("(", BEGINPARENTHESIS)
+	dict.AddWordWithTag(")", ENDPARENTHESIS)
+	dict.AddWordWithTag("\t", TAB)
+	for _, form := range []string{"pomme", "pomme de terre", "de", "terre", "fin", "fin\nde ligne", "ligne"} {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Lemma: form})
+		dict.AddWord(form, &word)
+	}
+	return dict
+}
+
+func GetStreamText() string {
+	var b strings.Builder
+	lines := []string{
+		"L'homme aime la pomme de terre.",
+		"  Il aime aujourd'hui (une pomme.\nde terre) fin",
+		"de ligne\tpomme de\nterre",
+		"12 h 30 @babble 3°C.",
+		"  (homme) presqu'île.",
+		"Le XXIe siècle, 10 000 € et 12,5 %.",
+	}
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&b, "%s\n", lines[i%len(lines)])
+		if i%len(lines) == len(lines)-1 {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("fin sans retour café")
+	return b.String()
+}
+
+func TokenSignature(text string, token Token) string {
+	description := "unknown"
+	if token.Word != nil {
+		description = token.Word.Description()
+	}
+	return fmt.Sprintf("%d-%d %q %s", token.Pos[0], token.Pos[1], text[token.Pos[0]:token.Pos[1]], description)
+}
+
+func TestTokenStream(t *testing.T) {
+	context := NewTestContext(GetStreamDictionary())
+	lines := GetStreamText()
+
+	for _, text := range []string{lines, strings.ReplaceAll(lines, "\n", " ")} {
+		for _, compound := range []bool{false, true} {
+			expected := Tokenize(text, context, compound)
+			for _, size := range []int{1, 7, 64, streamReadSize} {
+				stream := NewTokenStream(strings.NewReader(text), context, compound)
+				stream.size = size
+				i := 0
+				for stream.Scan() {
+					token := stream.Token()
+					if i >= len(expected) || TokenSignature(text, token) != TokenSignature(text, expected[i]) {
+						t.Fatalf("compound %t size %d token %d is %s", compound, size, i, TokenSignature(text, token))
+					}
+					if stream.Text() != expected[i].Content(text) {
+						t.Errorf("bad token text %s", stream.Text())
+					}
+					i++
+				}
+				if stream.Err() != nil || i != len(expected) {
+					t.Errorf("compound %t size %d: %d tokens of %d %v", compound, size, i, len(expected), stream.Err())
+				}
+			}
+		}
+	}
+}
+
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestTokenStreamLine(t *testing.T) {
+	context := NewTestContext(GetStreamDictionary())
+
+	// a single line is tokenized as it is read
+	text := strings.Repeat("l'homme aime la pomme de terre, ", 2000)
+	reader := &countingReader{r: strings.NewReader(text)}
+	stream := NewTokenStream(reader, context, true)
+	stream.size = 64
+	if !stream.Scan() || reader.read > 256 {
+		t.Errorf("first token after %d bytes", reader.read)
+	}
+	for stream.Scan() {
+		if len(stream.text) > 256 {
+			t.Fatalf("%d bytes buffered", len(stream.text))
+		}
+	}
+
+	// the tokens before a too long segment are those of Tokenize
+	text = "homme homme " + strings.Repeat("homme", 200)
+	expected := Tokenize(text, context, true)
+	stream = NewTokenStream(strings.NewReader(text), context, true)
+	stream.size = 16
+	stream.limit = 100
+	i := 0
+	for stream.Scan() {
+		if TokenSignature(text, stream.Token()) != TokenSignature(text, expected[i]) {
+			t.Errorf("token %d is %s", i, TokenSignature(text, stream.Token()))
+		}
+		i++
+	}
+	if i != 4 || stream.Err() != ErrSegmentTooLong {
+		t.Errorf("%d tokens before %v", i, stream.Err())
+	}
+}
+
+func TestSentenceStream(t *testing.T) {
+	context := NewTestContext(GetStreamDictionary())
+	text := GetStreamText()
+	expected := TokenizeSentence(text, context)
+	if len(expected) < 20 {
+		t.Fatalf("too few sentences %d", len(expected))
+	}
+
+	for _, size := range []int{1, 13, streamReadSize} {
+		stream := NewSentenceStream(strings.NewReader(text), context)
+		stream.tokens.size = size
+		i := 0
+		for stream.Scan() {
+			sentence := stream.Sentence()
+			e := expected[i]
+			if sentence.Type != e.Type || len(sentence.Tokens) != len(e.Tokens) {
+				t.Fatalf("size %d sentence %d is %s", size, i, sentence.Content(text))
+			}
+			for k := range e.Tokens {
+				if TokenSignature(text, sentence.Tokens[k]) != TokenSignature(text, e.Tokens[k]) {
+					t.Errorf("size %d sentence %d token %d", size, i, k)
+				}
+			}
+			start, end := e.Tokens[0].Pos[0], e.Tokens[len(e.Tokens)-1].Pos[1]
+			if stream.Text() != text[start:end] {
+				t.Errorf("bad sentence text %q", stream.Text())
+			}
+			i++
+		}
+		if stream.Err() != nil || i != len(expected) {
+			t.Errorf("size %d: %d sentences of %d %v", size, i, len(expected), stream.Err())
+		}
+	}
+}
+
+type failingReader struct {
+	r io.Reader
+}
+
+func (f *failingReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	if err == io.EOF {
+		return n, errors.New("connection reset")
+	}
+	return n, err
+}
+
+func TestTokenStreamError(t *testing.T) {
+	context := NewTestContext(GetStreamDictionary())
+	stream := NewTokenStream(&failingReader{strings.NewReader("homme\nhomme")}, context, true)
+	for stream.Scan() {
+	}
+	if stream.Err() == nil || stream.Err().Error() != "connection reset" {
+		t.Errorf("error not reported %v", stream.Err())
+	}
+}
+
+func SentenceContents(text string, sentences []TokenSentence) (contents []string) {
+	for _, s := range sentences {
+		contents = append(contents, s.Content(text))
+	}
+	return
+}
+
+func TestSentenceParenthesis(t *testing.T) {
+	context := NewTestContext(GetStreamDictionary())
+
+	// a parenthesis extends the sentence to the last closing one
+	text := "(homme.) pomme. (terre.) fin."
+	contents := SentenceContents(text, TokenizeSentence(text, context))
+	if len(contents) != 1 || contents[0] != "[(homme.) pomme. (terre.) fin.]type 2" {
+		t.Errorf("bad parenthesis sentences %v", contents)
+	}
+
+	// and stops at a blank line, in batch and in the stream
+	text = "(homme.) pomme. \n\n(terre.) fin."
+	expected := []string{"[(homme.) pomme. ]type 3", "[\n\n(terre.) fin.]type 1"}
+	stream := NewSentenceStream(strings.NewReader(text), context)
+	var sentences []TokenSentence
+	for stream.Scan() {
+		sentences = append(sentences, stream.Sentence())
+	}
+	for _, contents := range [][]string{SentenceContents(text, TokenizeSentence(text, context)), SentenceContents(text, sentences)} {
+		if strings.Join(contents, "|") != strings.Join(expected, "|") {
+			t.Errorf("bad paragraph parenthesis sentences %q", contents)
+		}
+	}
+
+	// or after sentenceParenTokens tokens
+	text = "(" + strings.Repeat("homme. ", 2000)
+	n := len(TokenizeSentence(text, context))
+	stream = NewSentenceStream(strings.NewReader(text), context)
+	stream.tokens.size = 64
+	for stream.Scan() {
+		if len(stream.splitter.tokens) > 2*sentenceParenTokens {
+			t.Fatalf("%d tokens kept", len(stream.splitter.tokens))
+		}
+		n--
+	}
+	if n != 0 || stream.Err() != nil {
+		t.Errorf("unmatched parenthesis sentences differ by %d %v", n, stream.Err())
+	}
+}
@@ -24,12 +24,50 @@ func ReplaceAtPos(word string, pos int, r rune, w int) string {
 	return start + middle + end
 }
 
+// number of runes that differ at the same position, extra runes
+// of the longest string count as errors
 func CountError(correct string, alt string) int {
 	var errors int
-	for i := 0; i < len(correct); i++ {
-		if correct[i] != alt[i] {
+	c := []rune(correct)
+	a := []rune(alt)
+	for i := 0; i < len(c) || i < len(a); i++ {
+		if i >= len(c) || i >= len(a) || c[i] != a[i] {
 			errors++
 		}
 	}
 	return errors
 }
+
+// damerau-levenshtein distance (optimal string alignment) in runes
+func EditDistance(s string, t string) int {
+	a := []rune(s)
+	b := []rune(t)
+
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = d[i-1][j-1] + cost
+			if d[i-1][j]+1 < d[i][j] {
+				d[i][j] = d[i-1][j] + 1
+			}
+			if d[i][j-1]+1 < d[i][j] {
+				d[i][j] = d[i][j-1] + 1
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
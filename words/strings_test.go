@@ -21,3 +21,32 @@ func TestReplaceAtPos(t *testing.T) {
 		t.Errorf("could not replace aest")
 	}
 }
+
+func TestCountError(t *testing.T) {
+	if CountError("tast", "test") != 1 {
+		t.Errorf("tast test errors")
+	}
+	if CountError("élève", "elève") != 1 {
+		t.Errorf("élève elève errors")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	distances := []struct {
+		s        string
+		t        string
+		distance int
+	}{
+		{"apel", "appel", 1},
+		{"recevoire", "recevoir", 1},
+		{"tset", "test", 1},
+		{"ecole", "école", 1},
+		{"chat", "chien", 3},
+		{"", "abc", 3},
+	}
+	for _, d := range distances {
+		if EditDistance(d.s, d.t) != d.distance {
+			t.Errorf("%s %s distance %d", d.s, d.t, EditDistance(d.s, d.t))
+		}
+	}
+}
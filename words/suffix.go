@@ -0,0 +1,98 @@
+package words
+
+import (
+	"sort"
+)
+
+// indexes word by its reversed form, the nodes share the words of the
+// trie without being their LastLetter
+func (dict *Dictionary) addSuffix(letters string, word *Word) {
+	runes := []rune(letters)
+	node := dict.suffixes
+	for i := len(runes) - 1; i >= 0; i-- {
+		if node.Children == nil {
+			node.Children = make(map[rune]*WordLetter)
+		}
+		child := node.Children[runes[i]]
+		if child == nil {
+			child = &WordLetter{Letter: runes[i], Parent: node}
+			node.Children[runes[i]] = child
+		}
+		node = child
+	}
+	node.Word = word
+}
+
+// indexes the words already added, AddWord keeps the index up to date
+// once it exists so dictionaries never queried by suffix do not pay
+// for a second trie
+func (dict *Dictionary) indexSuffixes() {
+	dict.suffixOnce.Do(func() {
+		dict.suffixes = &WordLetter{}
+		dict.root.each(-1, -1, func(w *Word) bool {
+			dict.addSuffix(w.String(), w)
+			return true
+		})
+	})
+}
+
+// words ending with suffix with a variant matching filter in rune
+// order, filter may be nil
+func (dict *Dictionary) FindSuffix(suffix string, filter *WordVariant) []*Word {
+	var words []*Word
+
+	dict.indexSuffixes()
+	runes := []rune(suffix)
+	node := dict.suffixes
+	for i := len(runes) - 1; i >= 0 && node != nil; i-- {
+		node = node.Children[runes[i]]
+	}
+	if node == nil {
+		return nil
+	}
+	node.each(-1, -1, func(w *Word) bool {
+		if w.HasVariant(filter) {
+			words = append(words, w)
+		}
+		return true
+	})
+	sort.Slice(words, func(i, j int) bool { return words[i].String() < words[j].String() })
+	return words
+}
+
+// lemmas of the variants matching filter of the words ending with
+// suffix, the nouns whose feminine ends in -euse are
+// FindSuffixLemmas("euse", &WordVariant{Tag: NOUN, Gender: FEMALE})
+func (dict *Dictionary) FindSuffixLemmas(suffix string, filter *WordVariant) []string {
+	var lemmas []string
+
+	seen := make(map[string]bool)
+	for _, w := range dict.FindSuffix(suffix, filter) {
+		for i := range w.Variants {
+			v := &w.Variants[i]
+			if len(v.Lemma) == 0 || seen[v.Lemma] || (filter != nil && !filter.Matches(v)) {
+				continue
+			}
+			seen[v.Lemma] = true
+			lemmas = append(lemmas, v.Lemma)
+		}
+	}
+	sort.Strings(lemmas)
+	return lemmas
+}
+
+// other words ending with the last size runes of form
+func (dict *Dictionary) Rhymes(form string, size int, filter *WordVariant) []*Word {
+	var words []*Word
+
+	runes := []rune(form)
+	if size <= 0 || size > len(runes) {
+		size = len(runes)
+	}
+	for _, w := range dict.FindSuffix(string(runes[len(runes)-size:]), filter) {
+		if w.String() != form {
+			words = append(words, w)
+		}
+	}
+	return words
+}
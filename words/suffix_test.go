@@ -0,0 +1,89 @@
+package words
+
+import (
+	"strings"
+	"testing"
+)
+
+func GetSuffixDictionary() *Dictionary {
+	dict := GetPatternDictionary()
+	nouns := []struct {
+		form   string
+		lemma  string
+		gender byte
+	}{
+		{"chanteur", "chanteur", MALE},
+		{"chanteuse", "chanteur", FEMALE},
+		{"danseuse", "danseur", FEMALE},
+		{"berceuse", "berceuse", FEMALE},
+	}
+	for _, n := range nouns {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Gender: n.gender, Number: SINGULAR, Lemma: n.lemma})
+		dict.AddWord(n.form, &word)
+	}
+	verb := Word{}
+	verb.Variants = append(verb.Variants, WordVariant{Tag: VERB, Language: FRENCH, Tense: INF, Lemma: "recevoir"})
+	dict.AddWord("recevoir", &verb)
+	return dict
+}
+
+func SuffixForms(words []*Word) string {
+	var forms []string
+	for _, w := range words {
+		forms = append(forms, w.String())
+	}
+	return strings.Join(forms, " ")
+}
+
+func TestFindSuffix(t *testing.T) {
+	dict := GetSuffixDictionary()
+
+	expected := []struct {
+		suffix string
+		filter *WordVariant
+		words  string
+	}{
+		{"ment", nil, "rapidement rarement refroidissement"},
+		{"ment", &WordVariant{Tag: ADVERB}, "rapidement rarement"},
+		{"oir", &WordVariant{Tag: VERB}, "recevoir"},
+		{"euse", &WordVariant{Tag: NOUN, Gender: FEMALE}, "berceuse chanteuse danseuse"},
+		{"nes", nil, "chaînes chiennes"},
+		{"chien", nil, "chien"},
+		{"xyz", nil, ""},
+	}
+	for _, e := range expected {
+		words := SuffixForms(dict.FindSuffix(e.suffix, e.filter))
+		if words != e.words {
+			t.Errorf("-%s gives '%s' not '%s'", e.suffix, words, e.words)
+		}
+	}
+
+	lemmas := dict.FindSuffixLemmas("euse", &WordVariant{Tag: NOUN, Gender: FEMALE})
+	if strings.Join(lemmas, " ") != "berceuse chanteur danseur" {
+		t.Errorf("bad lemmas %v", lemmas)
+	}
+
+	rhymes := SuffixForms(dict.Rhymes("chanteuse", 4, nil))
+	if rhymes != "berceuse danseuse" {
+		t.Errorf("bad rhymes '%s'", rhymes)
+	}
+}
+
+func TestFindSuffixLazy(t *testing.T) {
+	dict := GetSuffixDictionary()
+	if dict.suffixes != nil {
+		t.Fatalf("suffix index built before any suffix query")
+	}
+	if words := SuffixForms(dict.FindSuffix("eur", nil)); words != "chanteur" {
+		t.Errorf("-eur gives '%s'", words)
+	}
+
+	// words added once the index exists are indexed too
+	word := Word{}
+	word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH, Lemma: "danseur"})
+	dict.AddWord("danseur", &word)
+	if words := SuffixForms(dict.FindSuffix("eur", nil)); words != "chanteur danseur" {
+		t.Errorf("-eur gives '%s' after AddWord", words)
+	}
+}
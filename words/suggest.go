@@ -0,0 +1,136 @@
+package words
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+type Suggestion struct {
+	Word     *Word
+	Distance int // damerau-levenshtein distance to the searched word
+}
+
+// the row of the optimal string alignment matrix after r, parent is the
+// letter before r, min is the smallest distance of the row
+func suggestRow(target []rune, prev2 []int, prev []int, parent rune, r rune) (row []int, min int) {
+	row = make([]int, len(target)+1)
+	row[0] = prev[0] + 1
+	min = row[0]
+	for j := 1; j <= len(target); j++ {
+		cost := 1
+		if target[j-1] == r {
+			cost = 0
+		}
+		row[j] = prev[j-1] + cost
+		if prev[j]+1 < row[j] {
+			row[j] = prev[j] + 1
+		}
+		if row[j-1]+1 < row[j] {
+			row[j] = row[j-1] + 1
+		}
+		// transposition of r and the previous letter
+		if prev2 != nil && j > 1 && r == target[j-2] && parent == target[j-1] && prev2[j-2]+1 < row[j] {
+			row[j] = prev2[j-2] + 1
+		}
+		if row[j] < min {
+			min = row[j]
+		}
+	}
+	return
+}
+
+// walks the trie with one row of the optimal string alignment matrix
+// per letter, subtrees whose row minimum exceeds maxdistance are pruned
+func (letter *WordLetter) suggest(target []rune, prev2 []int, prev []int, maxdistance int, fn func(*Word, int)) {
+	for _, r := range letter.sortedRunes() {
+		child := letter.Children[r]
+		row, min := suggestRow(target, prev2, prev, letter.Letter, r)
+		if child.Word != nil && row[len(target)] <= maxdistance {
+			fn(child.Word, row[len(target)])
+		}
+		if min <= maxdistance {
+			child.suggest(target, prev, row, maxdistance, fn)
+		}
+	}
+}
+
+// the same walk on the automaton, letter is the rune leading to node
+func (dict *CompactDictionary) suggest(node uint32, index uint32, prefix []byte, letter rune, target []rune, prev2 []int, prev []int, maxdistance int, fn func(*Word, int)) {
+	if dict.final(node) {
+		index++
+	}
+	start, count := dict.nodeEdges(node)
+	for e := start; e < start+count; e++ {
+		r, child, skip := dict.edge(e)
+		row, min := suggestRow(target, prev2, prev, letter, r)
+		form := utf8.AppendRune(prefix, r)
+		if dict.final(child) && row[len(target)] <= maxdistance {
+			fn(dict.word(index+skip, string(form)), row[len(target)])
+		}
+		if min <= maxdistance {
+			dict.suggest(child, index+skip, form, r, target, prev, row, maxdistance, fn)
+		}
+	}
+}
+
+// words within maxdistance edits of word, closest first, lang 0
+// accepts every language and limit 0 returns every suggestion
+func (dict *Dictionary) Suggest(word string, lang byte, maxdistance int, limit int) []Suggestion {
+	var suggestions []Suggestion
+
+	target := []rune(word)
+	row := make([]int, len(target)+1)
+	for j := range row {
+		row[j] = j
+	}
+
+	dict.root.suggest(target, nil, row, maxdistance, func(w *Word, distance int) {
+		if lang == 0 || w.Language(lang) {
+			suggestions = append(suggestions, Suggestion{w, distance})
+		}
+	})
+
+	SortSuggestions(suggestions, dict.Frequencies)
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// words within maxdistance edits of word as Dictionary.Suggest, without
+// frequencies the closest come first by form
+func (dict *CompactDictionary) Suggest(word string, lang byte, maxdistance int, limit int) []Suggestion {
+	var suggestions []Suggestion
+
+	target := []rune(word)
+	row := make([]int, len(target)+1)
+	for j := range row {
+		row[j] = j
+	}
+	dict.suggest(dict.root, 0, nil, 0, target, nil, row, maxdistance, func(w *Word, distance int) {
+		if lang == 0 || w.Language(lang) {
+			suggestions = append(suggestions, Suggestion{w, distance})
+		}
+	})
+
+	SortSuggestions(suggestions, nil)
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// closest first, then most frequent in freq which may be nil
+func SortSuggestions(suggestions []Suggestion, freq *Frequencies) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Distance != suggestions[j].Distance {
+			return suggestions[i].Distance < suggestions[j].Distance
+		}
+		fi := freq.Form(suggestions[i].Word.String())
+		fj := freq.Form(suggestions[j].Word.String())
+		if fi != fj {
+			return fi > fj
+		}
+		return suggestions[i].Word.String() < suggestions[j].Word.String()
+	})
+}
@@ -15,25 +15,34 @@ const (
 )
 
 type TokenizeContext struct {
-	dict    *Dictionary
-	rtime   *regexp.Regexp
-	rtemp   *regexp.Regexp
-	rnumber *regexp.Regexp
-	rroman  *regexp.Regexp
-	rhex    *regexp.Regexp
-	rdate   *regexp.Regexp
-	rurl    *regexp.Regexp
+	store       *LexiconStore
+	snapshot    *Snapshot // pinned for one tokenization, nil reads the store
+	recognizers *Recognizers
 }
 
 type Token struct {
-	Pos      []int
-	Word     *Word
-	IsNumber bool
-	IsTime   bool
-	IsDate   bool
-	IsTemp   bool
-	IsURL    bool
-	IsUpper  bool
+	Pos     []int
+	Word    *Word
+	IsUpper bool
+
+	// entity found by the recognizers when Word is nil, Value is its
+	// normalized value
+	Kind  TokenKind
+	Value interface{}
+
+	// matched after folding case and diacritics, Word is the first
+	// of the Candidates ranked by SortFolded
+	IsFolded   bool
+	Candidates []*Word
+
+	// postposed clitic or euphonic t after a verb, Pos starts at the
+	// hyphen as in -il, see TokenizeClitics
+	IsPostposed bool
+}
+
+// lexicons finding words by their folded form
+type FoldedLexicon interface {
+	FindFolded(s string) []*Word
 }
 
 type TokenSentence struct {
@@ -41,62 +50,101 @@ type TokenSentence struct {
 	Type   byte
 }
 
+// loads the binary of DefaultManifest
 func TokenizeNewContext() (context *TokenizeContext, err error) {
-	context = new(TokenizeContext)
-
-	// compile regexp
-	context.rtime, err = regexp.Compile("[0-6]{1,2}(h|:)[0-6][0-9]")
-	if err != nil {
-		panic(err)
-		return
-	}
+	return TokenizeNewContextFromManifest(DefaultManifest())
+}
 
-	context.rtemp, err = regexp.Compile("-?[0-9]+°(C|F|K)")
+// tokenize with the lexicon of a manifest file
+func TokenizeNewManifestContext(path string) (context *TokenizeContext, err error) {
+	m, err := ReadManifest(path)
 	if err != nil {
-		panic(err)
 		return
 	}
+	return TokenizeNewContextFromManifest(m)
+}
 
-	context.rnumber, err = regexp.Compile("[0-9]+e?")
+// GetDictionary returns nil when the manifest has a compact dictionary
+func TokenizeNewContextFromManifest(m *Manifest) (context *TokenizeContext, err error) {
+	context, err = tokenizeNewContext()
 	if err != nil {
-		panic(err)
 		return
 	}
 
-	context.rroman, err = regexp.Compile("[MDCLXVI]+e?")
-	if err != nil {
-		panic(err)
-		return
-	}
+	// load dicts
+	context.store, err = NewManifestStore(m)
+	return
+}
 
-	context.rhex, err = regexp.Compile("(0x)?[0-9a-fA-F]+")
+// tokenize with a compact dictionary, GetDictionary returns nil
+func TokenizeNewCompactContext(path string) (context *TokenizeContext, err error) {
+	context, err = tokenizeNewContext()
 	if err != nil {
-		panic(err)
 		return
 	}
+	context.store, err = NewCompactStore(path)
+	return
+}
 
-	context.rdate, err = regexp.Compile("[0-9]{2,4}/[0-9]{2}/[0-9]{2,4}")
-	if err != nil {
-		panic(err)
-		return
-	}
-	context.rurl, err = regexp.Compile(`((http|https|ftp)://)?([0-9a-z_-]+\x2E)+(aero|asia|biz|cat|com|coop|edu|gov|info|int|jobs|mil|mobi|museum|name|net|org|pro|tel|travel|ac|ad|ae|af|ag|ai|al|am|an|ao|aq|ar|as|at|au|aw|ax|az|ba|bb|bd|be|bf|bg|bh|bi|bj|bm|bn|bo|br|bs|bt|bv|bw|by|bz|ca|cc|cd|cf|cg|ch|ci|ck|cl|cm|cn|co|cr|cu|cv|cx|cy|cz|cz|de|dj|dk|dm|do|dz|ec|ee|eg|er|es|et|eu|fi|fj|fk|fm|fo|fr|ga|gb|gd|ge|gf|gg|gh|gi|gl|gm|gn|gp|gq|gr|gs|gt|gu|gw|gy|hk|hm|hn|hr|ht|hu|id|ie|il|im|in|io|iq|ir|is|it|je|jm|jo|jp|ke|kg|kh|ki|km|kn|kp|kr|kw|ky|kz|la|lb|lc|li|lk|lr|ls|lt|lu|lv|ly|ma|mc|md|me|mg|mh|mk|ml|mn|mn|mo|mp|mr|ms|mt|mu|mv|mw|mx|my|mz|na|nc|ne|nf|ng|ni|nl|no|np|nr|nu|nz|nom|pa|pe|pf|pg|ph|pk|pl|pm|pn|pr|ps|pt|pw|py|qa|re|ra|rs|ru|rw|sa|sb|sc|sd|se|sg|sh|si|sj|sj|sk|sl|sm|sn|so|sr|st|su|sv|sy|sz|tc|td|tf|tg|th|tj|tk|tl|tm|tn|to|tp|tr|tt|tv|tw|tz|ua|ug|uk|us|uy|uz|va|vc|ve|vg|vi|vn|vu|wf|ws|ye|yt|yu|za|zm|zw|arpa)(:[0-9]+)?(/[[0-9a-z\/\?\=\#\(\)_\-\.]+)?`)
+// tokenize with the snapshots of store, contexts sharing a store see
+// its reloads and runtime words
+func TokenizeNewStoreContext(store *LexiconStore) (context *TokenizeContext, err error) {
+	context, err = tokenizeNewContext()
 	if err != nil {
-		panic(err)
 		return
 	}
+	context.store = store
+	return
+}
 
-	// load dicts
-	context.dict = new(Dictionary)
-	err = context.dict.ReadBinary("lm/lm.bin")
-	if err != nil {
-		return
-	}
+func tokenizeNewContext() (context *TokenizeContext, err error) {
+	context = new(TokenizeContext)
+	context.recognizers = DefaultRecognizers()
 	return
 }
 
 func(context *TokenizeContext) GetDictionary() *Dictionary {
-	return context.dict
+	return context.Snapshot().Dict
+}
+
+func (context *TokenizeContext) GetLexicon() Lexicon {
+	return context.Snapshot().Lexicon
+}
+
+func (context *TokenizeContext) GetStore() *LexiconStore {
+	return context.store
+}
+
+// the recognizers of tokens without word, shared by the pinned copies
+// of context
+func (context *TokenizeContext) Recognizers() *Recognizers {
+	return context.recognizers
+}
+
+// tokenize with lexicon, for example a LayeredDictionary over the
+// current lexicon, GetDictionary is unchanged
+func (context *TokenizeContext) SetLexicon(lexicon Lexicon) {
+	context.store.SetBase(lexicon, context.store.Snapshot().Dict)
+}
+
+// the snapshot used by the context, the current one of the store
+// outside of a tokenization
+func (context *TokenizeContext) Snapshot() *Snapshot {
+	if context.snapshot != nil {
+		return context.snapshot
+	}
+	return context.store.Snapshot()
+}
+
+// copy of context reading the same snapshot until it is dropped, so a
+// reload does not change the lexicon in the middle of a text
+func (context *TokenizeContext) Pin() *TokenizeContext {
+	if context.snapshot != nil {
+		return context
+	}
+	pinned := *context
+	pinned.snapshot = context.store.Snapshot()
+	return &pinned
 }
 
 func (t *Token) Content(content string) string {
@@ -104,7 +152,7 @@ func (t *Token) Content(content string) string {
 }
 
 func (t *Token) IsValid() bool {
-	return t.Word != nil || t.IsNumber || t.IsTime || t.IsTemp || t.IsURL || t.IsDate
+	return t.Word != nil || t.Kind != 0
 }
 
 func (t *Token) String() string {
@@ -112,24 +160,18 @@ func (t *Token) String() string {
 	if t.Word != nil {
 		s += t.Word.Description()
 	}
-	if t.IsNumber {
-		s += "IsNumber "
-	}
-	if t.IsTime {
-		s += "IsTemp "
-	}
-	if t.IsDate {
-		s += "IsDate "
-	}
-	if t.IsTemp {
-		s += "IsTemp "
-	}
-	if t.IsURL {
-		s += "IsURL "
+	if t.Kind != 0 {
+		s += fmt.Sprintf("%s %v ", t.Kind, t.Value)
 	}
 	if t.IsUpper {
 		s += "IsUpper "
 	}
+	if t.IsFolded {
+		s += "IsFolded "
+	}
+	if t.IsPostposed {
+		s += "IsPostposed "
+	}
 	return s
 }
 
@@ -147,21 +189,58 @@ func TokenizeFindWord(s string, context *TokenizeContext) (word *Word, foundPath
 	r, _ := utf8.DecodeRuneInString(s)
 	isUpper := unicode.IsUpper(r)
 
-	word, foundPath = context.dict.FindWord(s)
+	lexicon := context.Snapshot().Lexicon
+	s = TokenizeNormalizeApostrophes(s)
+	word, foundPath = lexicon.FindWord(s)
 
 	// match lower case version of word
 	if word == nil && isUpper {
-		word, foundPath = context.dict.FindWord(TokenizeToLower(s))
+		word, foundPath = lexicon.FindWord(TokenizeToLower(s))
 	}
 	return word, foundPath
 }
 
+// accented candidates of s, most likely first, Dictionary,
+// CompactDictionary and LayeredDictionary are all FoldedLexicon
+func TokenizeFindFolded(s string, context *TokenizeContext) []*Word {
+	snapshot := context.Snapshot()
+	folder, ok := snapshot.Lexicon.(FoldedLexicon)
+	if !ok {
+		return nil
+	}
+	words := folder.FindFolded(TokenizeNormalizeApostrophes(s))
+	var freq *Frequencies
+	if snapshot.Dict != nil {
+		freq = snapshot.Dict.Frequencies
+	}
+	SortFolded(s, words, freq)
+	return words
+}
+
 func TokenizeToLower(s string) string {
 	return strings.ToLower(s)
 }
 
+// kind and value of s by the recognizers of context
+func TokenizeRecognize(s string, context *TokenizeContext) (kind TokenKind, value interface{}, ok bool) {
+	return context.recognizers.Recognize(s)
+}
+
+func TokenizeIsKind(s string, context *TokenizeContext, kinds ...TokenKind) bool {
+	kind, _, ok := TokenizeRecognize(s, context)
+	if !ok {
+		return false
+	}
+	for _, k := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+	return false
+}
+
 func TokenizeIsNumber(s string, context *TokenizeContext) bool {
-	return TokenizeMatchOnly(s, context.rnumber) || TokenizeMatchOnly(s, context.rroman) || TokenizeMatchOnly(s, context.rhex)
+	return TokenizeIsKind(s, context, NUMBER, ROMAN, HEX)
 }
 
 func TokenizeIsTag(t Token, tag byte) bool {
@@ -213,24 +292,19 @@ func TokenizeMatchOnly(s string, r *regexp.Regexp) bool {
 }
 
 func TokenizeIsTemp(s string, context *TokenizeContext) bool {
-	return TokenizeMatchOnly(s, context.rtemp)
+	return TokenizeIsKind(s, context, TEMP)
 }
 
 func TokenizeIsTime(s string, context *TokenizeContext) bool {
-	return TokenizeMatchOnly(s, context.rtime)
+	return TokenizeIsKind(s, context, TIME)
 }
 
 func TokenizeIsDate(s string, context *TokenizeContext) bool {
-	return TokenizeMatchOnly(s, context.rdate)
+	return TokenizeIsKind(s, context, DATE)
 }
 
 func TokenizeIsURL(s string, context *TokenizeContext) bool {
-	// twitter
-	if strings.HasPrefix(s, "@") {
-		return true
-	}
-
-	return TokenizeMatchOnly(s, context.rurl)
+	return TokenizeIsKind(s, context, URL)
 }
 
 func TokenizeIsWord(s string) bool {
@@ -270,44 +344,32 @@ func TokenizeIsProperNoun(s string) bool {
 func TokenizeBuildToken(content string, searchPath *bool, start int, end int, context *TokenizeContext) *Token {
 	var word *Word
 	var foundPath bool
+	var candidates []*Word
 
 	if *searchPath {
 		word, foundPath = TokenizeFindWord(content[start:end], context)
+		if word == nil {
+			candidates = TokenizeFindFolded(content[start:end], context)
+			if len(candidates) > 0 {
+				word = candidates[0]
+				foundPath = true
+			}
+		}
 		if !foundPath {
 			*searchPath = false
 		}
 	}
 
 	r, _ := utf8.DecodeRuneInString(content[start:end])
-	isUpper := unicode.IsUpper(r)
-	isNumber := false
-	isTime := false
-	isDate := false
-	isTemp := false
-	isURL := false
+	token := &Token{Pos: []int{start, end},
+		Word:       word,
+		IsUpper:    unicode.IsUpper(r),
+		IsFolded:   len(candidates) > 0,
+		Candidates: candidates}
 	if word == nil {
-		isNumber = TokenizeIsNumber(content[start:end], context)
-		if !isNumber {
-			isTime = TokenizeIsTime(content[start:end], context)
-		}
-		if !isNumber && !isTime {
-			isDate = TokenizeIsDate(content[start:end], context)
-		}
-		if !isNumber && !isTime && !isDate {
-			isTemp = TokenizeIsTemp(content[start:end], context)
-		}
-		if !isNumber && !isTime && !isDate && !isTemp {
-			isURL = TokenizeIsURL(content[start:end], context)
-		}
+		token.Kind, token.Value, _ = TokenizeRecognize(content[start:end], context)
 	}
-	return &Token{[]int{start, end},
-		word,
-		isNumber,
-		isTime,
-		isDate,
-		isTemp,
-		isURL,
-		isUpper}
+	return token
 }
 
 func TokenizeAddToken(content string, start int, end int, intoks []Token, context *TokenizeContext) (tokens []Token) {
@@ -322,6 +384,7 @@ func TokenizeAddToken(content string, start int, end int, intoks []Token, contex
 }
 
 func TokenizeCompoundToken(content string, intoks []Token, context *TokenizeContext) (tokens []Token) {
+	context = context.Pin()
 	for i := 0; i < len(intoks); {
 		var startPos int
 		var token *Token
@@ -331,9 +394,10 @@ func TokenizeCompoundToken(content string, intoks []Token, context *TokenizeCont
 		r := rune(content[intoks[i].Pos[0]])
 		isComp := !(unicode.IsSpace(r) || unicode.IsDigit(r))
 		searchPath := true
+		_, maxTokens := context.snapshot.Lexicon.Limits()
 
 		for j := i + 1; j < len(intoks) && isComp && searchPath; j++ {
-			if j-i <= context.dict.MaxTokens {
+			if j-i <= maxTokens {
 				compoundToken := TokenizeBuildToken(content, &searchPath, startPos, intoks[j].Pos[1], context)
 				if compoundToken.IsValid() {
 					token = compoundToken
@@ -356,10 +420,35 @@ func TokenizeCompoundToken(content string, intoks []Token, context *TokenizeCont
 }
 
 func Tokenize(content string, context *TokenizeContext, compound bool) (tokens []Token) {
+	context = context.Pin()
 	var r rune
 	for i, j, w := 0, 0, 0; i < len(content); i += w {
 		r, w = utf8.DecodeRuneInString(content[i:])
 		isAnd := r == '&'
+		if r >= '0' && r <= '9' && i == j {
+			if token := TokenizeNumber(content, i, context); token != nil {
+				tokens = append(tokens, *token)
+				j = token.Pos[1]
+				w = j - i
+				continue
+			}
+		}
+		if TokenizeIsApostrophe(r) && i > j {
+			if token := TokenizeElision(content, j, i, context); token != nil {
+				tokens = append(tokens, *token)
+				j = token.Pos[1]
+				w = j - i
+				continue
+			}
+		}
+		if r == '-' && i > j {
+			if clitics := TokenizeClitics(content, j, i, context); len(clitics) > 0 {
+				tokens = append(tokens, clitics...)
+				j = clitics[len(clitics)-1].Pos[1]
+				w = j - i
+				continue
+			}
+		}
 		if (unicode.IsPunct(r) || unicode.IsSpace(r)) && !isAnd {
 			if i > j {
 				tokens = TokenizeAddToken(content, j, i, tokens, context)
@@ -384,71 +473,159 @@ func TokenizeGetSentenceType(i int, j int, tokens []Token) byte {
 	return NOSENTENCE
 }
 
-func TokenizeSentence(content string, context *TokenizeContext) (s []TokenSentence) {
+// sentence splitting of tokens given in order, the batch
+// TokenizeSentence gives them all at once and a SentenceStream as they
+// are read
+type sentenceSplitter struct {
+	tokens []Token // from the token before the current sentence
+	offset int     // index of tokens[0]
+	i      int     // next token to split
+	j      int     // first token of the current sentence
+}
 
-	var j int
+// tokens after an opening parenthesis searched for the closing one, the
+// search also stops at a blank line so that an unmatched parenthesis
+// does not extend its sentence to the end of the text
+const sentenceParenTokens = 512
 
-	tokens := Tokenize(content, context, true)
+func (s *sentenceSplitter) at(i int) Token {
+	return s.tokens[i-s.offset]
+}
 
-	for i := 0; i < len(tokens); i++ {
+func (s *sentenceSplitter) slice(from int, to int) []Token {
+	return s.tokens[from-s.offset : to-s.offset]
+}
 
-		t := tokens[i]
+func (s *sentenceSplitter) add(tokens []Token) {
+	s.tokens = append(s.tokens, tokens...)
+}
+
+// true when a blank line starts at the token m
+func (s *sentenceSplitter) blankLine(m int, n int) bool {
+	if !TokenizeIsTag(s.at(m), EOL) {
+		return false
+	}
+	for m++; m < n; m++ {
+		t := s.at(m)
+		if TokenizeIsTag(t, EOL) {
+			return true
+		}
+		if !TokenizeIsSpace(t) && !TokenizeIsTag(t, CR) {
+			return false
+		}
+	}
+	return false
+}
+
+// the last closing parenthesis after the opening one at i up to a blank
+// line or sentenceParenTokens tokens, -1 when none, false when the next
+// tokens are needed to find it
+func (s *sentenceSplitter) closing(i int, n int, final bool) (k int, ok bool) {
+	k = -1
+	end := n
+	if i+1+sentenceParenTokens < n {
+		end = i + 1 + sentenceParenTokens
+	}
+	for m := i + 1; m < end; m++ {
+		if TokenizeIsEndParenthesis(s.at(m)) {
+			k = m
+		}
+		if s.blankLine(m, n) {
+			return k, true
+		}
+	}
+	return k, final || end < n
+}
+
+// sentences found so far, final when no token follows, otherwise it
+// stops at the first token whose sentence depends on the next tokens
+func (s *sentenceSplitter) split(final bool) (sentences []TokenSentence) {
+	n := s.offset + len(s.tokens)
+
+	for ; s.i < n; s.i++ {
+		i, j := s.i, s.j
+
+		// the last token ends the text
+		if !final && i == n-1 {
+			break
+		}
+
+		t := s.at(i)
 
 		// dot separated sentence
-		if TokenizeIsDot(t) && !TokenizeIsBeginParenthesis(tokens[j]) {
-			s = append(s, TokenSentence{tokens[j : i+1], SENTENCE})
-			j = i + 1
+		if TokenizeIsDot(t) && !TokenizeIsBeginParenthesis(s.at(j)) {
+			sentences = append(sentences, TokenSentence{s.slice(j, i+1), SENTENCE})
+			s.j = i + 1
 			continue
 		}
 
 		// separators [  A] [.  A] [\t  A]
-		if TokenizeIsSpace(t) && (i == 0 || i > 0 && TokenizeIsEnd(tokens[i-1])) {
-			var k int
-			for k = i; k < len(tokens); k++ {
-				if !TokenizeIsSpace(tokens[k]) {
-					s = append(s, TokenSentence{tokens[j:k], SEPARATOR})
-					i = k
-					j = k
-					break
-				}
+		if TokenizeIsSpace(t) && (i == 0 || i > 0 && TokenizeIsEnd(s.at(i-1))) {
+			k := i
+			for k < n && TokenizeIsSpace(s.at(k)) {
+				k++
+			}
+			if k < n {
+				sentences = append(sentences, TokenSentence{s.slice(j, k), SEPARATOR})
+				s.i = k
+				s.j = k
+				continue
 			}
-			if k == len(tokens) {
-				s = append(s, TokenSentence{tokens[j:len(tokens)], SEPARATOR})
-				i = k // reach end
-				j = k + 1
+			if !final {
+				break
 			}
+			sentences = append(sentences, TokenSentence{s.slice(j, n), SEPARATOR})
+			s.i = k // reach end
+			s.j = k + 1
 			continue
 		}
 
-		// ()
+		// () up to the last closing parenthesis
 		if TokenizeIsBeginParenthesis(t) {
-			var k int
-			for k = i; k < len(tokens); k++ {
-				if TokenizeIsEndParenthesis(tokens[k]) {
-					i = k
-					continue
-				}
+			k, ok := s.closing(i, n, final)
+			if !ok {
+				break
+			}
+			if k >= 0 {
+				i = k
+				s.i = k
 			}
 		}
 
 		// non sentence separator
 		if TokenizeIsTab(t) {
 			if i-j > 0 {
-				s = append(s, TokenSentence{tokens[j:i], NOSENTENCE})
+				sentences = append(sentences, TokenSentence{s.slice(j, i), NOSENTENCE})
 			}
-			j = i + 1 // skip sep
+			s.j = i + 1 // skip sep
 			continue
 		}
 
 		// end tokens
-		if i == len(tokens)-1 && j < len(tokens) && i-j > 0 {
-			t := TokenizeGetSentenceType(j, len(tokens)-1, tokens)
-			s = append(s, TokenSentence{tokens[j:len(tokens)], t})
+		if i == n-1 && j < n && i-j > 0 {
+			t := TokenizeGetSentenceType(0, n-1-j, s.slice(j, n))
+			sentences = append(sentences, TokenSentence{s.slice(j, n), t})
 		}
 	}
+
+	// keep the token before the current sentence
+	if drop := s.j - 1 - s.offset; drop > 0 && !final {
+		s.tokens = s.tokens[drop:]
+		s.offset += drop
+	}
 	return
 }
 
+// sentences of content, an opening parenthesis extends its sentence to
+// the last closing one of its paragraph, at most sentenceParenTokens
+// tokens away
+func TokenizeSentence(content string, context *TokenizeContext) (s []TokenSentence) {
+	context = context.Pin()
+	splitter := sentenceSplitter{}
+	splitter.add(Tokenize(content, context, true))
+	return splitter.split(true)
+}
+
 func TokenizePrintTokens(content string, tokens []Token) {
 	for _, t := range tokens {
 		fmt.Printf("'%s' word %s\n", content[t.Pos[0]:t.Pos[1]], t.Word)
@@ -54,6 +54,16 @@ func GetTokenizeContext() *TokenizeContext {
 	return context
 }
 
+func NewTestContext(lexicon Lexicon) *TokenizeContext {
+	context, err := tokenizeNewContext()
+	if err != nil {
+		panic("cannot create context")
+	}
+	dict, _ := lexicon.(*Dictionary)
+	context.store = NewLexiconStore(lexicon, dict)
+	return context
+}
+
 func SubTestTokens(t *testing.T, text string, tokens []Token, s TestSentence) {
 	if len(tokens) != len(s.tokens) {
 		t.Errorf("'%s' bad token len got %d should have %d", s.sentence, len(tokens), len(s.tokens))
@@ -439,3 +449,181 @@ func TestTokenizeNonSentenceWithURL(t *testing.T) {
 
 	SubTestSentenceTokens(t, text1, test1, context)
 }
+
+func TestTokenizeFolded(t *testing.T) {
+	dict := Dictionary{}
+	dict.AddBuiltin()
+	dict.AddWordWithTag(" ", SPACE)
+	for _, form := range []string{"école", "élève", "cœur", "table"} {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: NOUN, Language: FRENCH})
+		dict.AddWord(form, &word)
+	}
+	compact := GetCompactDictionary(t, &dict)
+	layered := &LayeredDictionary{Base: compact}
+	layered.Layers = append(layered.Layers, NewUserLayer("produits", ""))
+
+	// the same tokens whatever the lexicon format
+	for _, lexicon := range []Lexicon{&dict, compact, layered} {
+		context := NewTestContext(lexicon)
+		text := "ECOLE eleve coeur table Table"
+		tokens := Tokenize(text, context, false)
+		expected := []struct {
+			word   string
+			folded bool
+		}{
+			{"école", true}, {" ", false}, {"élève", true}, {" ", false},
+			{"cœur", true}, {" ", false}, {"table", false}, {" ", false}, {"table", false},
+		}
+		if len(tokens) != len(expected) {
+			t.Fatalf("%T: bad token count %d", lexicon, len(tokens))
+		}
+		for i, e := range expected {
+			if tokens[i].Word == nil || tokens[i].Word.String() != e.word || tokens[i].IsFolded != e.folded {
+				t.Errorf("%T: '%s' should match %s folded %t", lexicon, tokens[i].Content(text), e.word, e.folded)
+			}
+		}
+	}
+}
+
+func TestTokenizeFoldedRank(t *testing.T) {
+	dict := Dictionary{}
+	for _, form := range []string{"côté", "coté"} {
+		dict.AddWord(form, &Word{})
+	}
+	context := NewTestContext(&dict)
+
+	// the closest spelling without frequencies
+	tokens := Tokenize("cote", context, false)
+	if len(tokens[0].Candidates) != 2 || tokens[0].Word.String() != "coté" {
+		t.Errorf("cote matches %s", tokens[0].Word.String())
+	}
+
+	// the most frequent one with
+	dict.Frequencies = NewFrequencies()
+	dict.Frequencies.Forms["côté"] = 10
+	tokens = Tokenize("cote", context, false)
+	if tokens[0].Word.String() != "côté" {
+		t.Errorf("cote matches %s with frequencies", tokens[0].Word.String())
+	}
+}
+
+func GetElisionDictionary() *Dictionary {
+	dict := Dictionary{}
+	dict.AddBuiltin()
+	dict.AddWordWithTag(" ", SPACE)
+	dict.AddWordWithTag(",", COMMA)
+	dict.AddWordWithTag("'", APOS)
+	words := []struct {
+		form string
+		tag  byte
+	}{
+		{"homme", NOUN}, {"il", PRONOUN}, {"aime", VERB}, {"aujourd'hui", ADVERB},
+		{"presqu'île", NOUN}, {"île", NOUN}, {"à", PREP},
+	}
+	for _, w := range words {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: w.tag, Language: FRENCH, Lemma: w.form})
+		dict.AddWord(w.form, &word)
+	}
+	return &dict
+}
+
+func TestTokenizeElision(t *testing.T) {
+	context := NewTestContext(GetElisionDictionary())
+
+	text := "L’homme qu'il aime aujourd’hui, presqu'île jusqu’à 'homme'"
+	tokens := Tokenize(text, context, false)
+	expected := []string{"L’", "homme", " ", "qu'", "il", " ", "aime", " ", "aujourd’hui", ",", " ",
+		"presqu'île", " ", "jusqu’", "à", " ", "'", "homme", "'"}
+	if len(tokens) != len(expected) {
+		TokenizePrintTokens(text, tokens)
+		t.Fatalf("bad token count %d", len(tokens))
+	}
+	for i, e := range expected {
+		if tokens[i].Content(text) != e || tokens[i].Word == nil {
+			t.Errorf("token %d is '%s' not '%s'", i, tokens[i].Content(text), e)
+		}
+	}
+
+	elided := tokens[0].Word
+	if !tokens[0].IsUpper || !elided.Tagged(DET) || elided.Variants[0].Lemma != "le" || elided.Variants[0].Flags&ELIDED == 0 {
+		t.Errorf("bad elided article %s", elided.Description())
+	}
+	if !tokens[3].Word.Tagged(CONJS) || !tokens[13].Word.Tagged(PREP) {
+		t.Errorf("bad elided words %s %s", tokens[3].Word.Description(), tokens[13].Word.Description())
+	}
+	if !tokens[8].Word.Tagged(ADVERB) || !tokens[16].Word.Tagged(APOS) {
+		t.Errorf("bad apostrophe words %s %s", tokens[8].Word.Description(), tokens[16].Word.Description())
+	}
+}
+
+func TestTokenizeElisionLexicon(t *testing.T) {
+	dict := GetElisionDictionary()
+	word := Word{}
+	word.Variants = append(word.Variants, WordVariant{Tag: CONJS, Language: FRENCH, Lemma: "que"})
+	dict.AddWord("qu'", &word)
+	context := NewTestContext(dict)
+
+	text := "qu'il rock'n'roll"
+	tokens := Tokenize(text, context, false)
+	if tokens[0].Content(text) != "qu'" || tokens[0].Word.Variants[0].Flags&ELIDED == 0 {
+		t.Errorf("lexicon elision not flagged %s", tokens[0].Word.Description())
+	}
+	if word.Variants[0].Flags&ELIDED != 0 {
+		t.Errorf("lexicon word changed")
+	}
+	for _, token := range tokens[2:] {
+		if token.Word != nil && token.Word.Variants[0].Lemma == "ne" {
+			t.Errorf("%s elided before a consonant", token.Content(text))
+		}
+	}
+}
+
+func TestTokenizeClitics(t *testing.T) {
+	dict := GetElisionDictionary()
+	for _, form := range []string{"dit", "a", "va", "peut", "donne"} {
+		word := Word{}
+		word.Variants = append(word.Variants, WordVariant{Tag: VERB, Language: FRENCH, Tense: IND, Person: 3, Number: SINGULAR})
+		dict.AddWord(form, &word)
+	}
+	dict.AddWordWithTag("peut-être", ADVERB)
+	dict.AddWordWithTag("grand-mère", NOUN)
+	dict.AddWordWithTag("-", DASH)
+	dict.AddWordWithTag("ci", ADVERB)
+	context := NewTestContext(dict)
+
+	text := "Dit-il peut-être, peut-il a-t-elle va-t-en donne-le-moi va-t'en grand-mère homme-ci"
+	tokens := Tokenize(text, context, false)
+	expected := []struct {
+		token string
+		tag   byte
+	}{
+		{"Dit", VERB}, {"-il", PRONOUN}, {" ", SPACE}, {"peut-être", ADVERB}, {",", COMMA}, {" ", SPACE},
+		{"peut", VERB}, {"-il", PRONOUN}, {" ", SPACE}, {"a", VERB}, {"-t", PART}, {"-elle", PRONOUN}, {" ", SPACE},
+		{"va", VERB}, {"-t", PRONOUN}, {"-en", PRONOUN}, {" ", SPACE}, {"donne", VERB}, {"-le", PRONOUN}, {"-moi", PRONOUN}, {" ", SPACE},
+		{"va", VERB}, {"-t'", PRONOUN}, {"en", 0}, {" ", SPACE}, {"grand-mère", NOUN}, {" ", SPACE},
+		{"homme", NOUN}, {"-", DASH}, {"ci", ADVERB},
+	}
+	if len(tokens) != len(expected) {
+		TokenizePrintTokens(text, tokens)
+		t.Fatalf("bad token count %d", len(tokens))
+	}
+	for i, e := range expected {
+		token := tokens[i]
+		if token.Content(text) != e.token || (e.tag != 0 && (token.Word == nil || !token.Word.Tagged(e.tag))) {
+			t.Errorf("token %d is '%s' not '%s' %s", i, token.Content(text), e.token, TagCode(e.tag))
+		}
+	}
+
+	il := tokens[1].Word.Variants[0]
+	if il.Lemma != "il" || il.Person != 3 || il.Flags&POSTPOS != 0 || !tokens[1].IsPostposed {
+		t.Errorf("bad clitic %s", il.String())
+	}
+	if tokens[0].IsPostposed || !tokens[10].IsPostposed || tokens[28].IsPostposed {
+		t.Errorf("verb, euphonic t or dash badly marked")
+	}
+	if tokens[22].Word.Variants[0].Flags&ELIDED == 0 {
+		t.Errorf("t' not elided")
+	}
+}
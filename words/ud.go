@@ -0,0 +1,220 @@
+package words
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// conversion between WordVariant and the UPOS and FEATS columns of
+// Universal Dependencies CoNLL-U files
+//
+// lost from babble to UD:
+//   - Language and the flags other than PROPER
+//   - the subcats other than Dem and Indef: Hum, Anl, Conc, Abst,
+//     Unit, Temp
+//   - noun phrase, proper name and compound tags (GN, GNP, NA, PCDN3,
+//     CLARKN...) become NOUN, PROPN or VERB
+//   - punctuation and symbol kinds become PUNCT and SYM, the white
+//     space tags (SPACE, TAB, EOL, CR, NBNS) become X
+//   - PREPDET and PREPPRO, du or auquel, are one ADP where UD splits
+//     them in two words
+//
+// lost from UD to babble:
+//   - the tense of finite verbs, IND covers Pres, Imp, Past and Fut
+//   - AUX becomes VERB and NUM becomes DET
+//   - PUNCT and SYM give no tag, the kind is known from the form
+//   - every feature without babble equivalent: Definite, Polarity,
+//     Poss, Reflex, NumType, PronType other than Dem and Ind...
+//   - the lemma which is its own CoNLL-U column
+
+var udTags = []struct {
+	tag  byte
+	upos string
+}{
+	{NOUN, "NOUN"},
+	{PREP, "ADP"},
+	{ADVERB, "ADV"},
+	{VERB, "VERB"},
+	{ADJ, "ADJ"},
+	{NOMINALDET, "DET"},
+	{PREFIX, "X"},
+	{GNP, "NOUN"},
+	{GNPX, "NOUN"},
+	{CONJS, "SCONJ"},
+	{CONJ, "CCONJ"},
+	{GN, "NOUN"},
+	{CONJC, "CCONJ"},
+	{PRONOUN, "PRON"},
+	{PREPADJ, "ADP"},
+	{PREPDET, "ADP"},
+	{PREPPRO, "ADP"},
+	{INTJ, "INTJ"},
+	{DET, "DET"},
+	{PRON, "PRON"},
+	{VA, "VERB"},
+	{NA, "NOUN"},
+	{CLARKN, "PROPN"},
+	{GHERYN, "PROPN"},
+	{GWELLSN, "PROPN"},
+	{MAYERN, "PROPN"},
+	{NES, "PROPN"},
+	{MAUGHAMN, "PROPN"},
+	{ADVA, "ADV"},
+	{CFIELDSN, "PROPN"},
+	{X, "X"},
+	{PCDN3, "NOUN"},
+	{XI, "X"},
+	{PART, "PART"},
+	{PRED, "VERB"},
+	{HARTN, "PROPN"},
+	{ABBR, "X"},
+	{TAB, "X"},
+	{QUOTATIONMARK, "PUNCT"},
+	{BEGINQUOTATION, "PUNCT"},
+	{ENDQUOTATION, "PUNCT"},
+	{APOS, "PUNCT"},
+	{SLASH, "SYM"},
+	{DASH, "PUNCT"},
+	{GREATHERTHAN, "SYM"},
+	{SMALLERTHAN, "SYM"},
+	{SPACE, "X"},
+	{COMMA, "PUNCT"},
+	{SEMICOLON, "PUNCT"},
+	{DOT, "PUNCT"},
+	{COLON, "PUNCT"},
+	{EXCLAMATIONMARK, "PUNCT"},
+	{QUESTIONMARK, "PUNCT"},
+	{BEGINPARENTHESIS, "PUNCT"},
+	{ENDPARENTHESIS, "PUNCT"},
+	{BEGINBRACKET, "PUNCT"},
+	{ENDBRACKET, "PUNCT"},
+	{NUMBERSIGN, "SYM"},
+	{DOLLARSIGN, "SYM"},
+	{COPYRIGHTSIGN, "SYM"},
+	{ATSIGN, "SYM"},
+	{NBNS, "X"},
+	{AND, "SYM"},
+	{OR, "SYM"},
+	{EOL, "X"},
+	{CR, "X"},
+}
+
+// UPOS to tag, PROPN is a NOUN with the PROPER flag
+var udUpos = map[string]byte{
+	"NOUN":  NOUN,
+	"PROPN": NOUN,
+	"ADP":   PREP,
+	"ADV":   ADVERB,
+	"VERB":  VERB,
+	"AUX":   VERB,
+	"ADJ":   ADJ,
+	"DET":   DET,
+	"NUM":   DET,
+	"SCONJ": CONJS,
+	"CCONJ": CONJC,
+	"PRON":  PRONOUN,
+	"INTJ":  INTJ,
+	"PART":  PART,
+	"X":     X,
+	"PUNCT": 0,
+	"SYM":   0,
+}
+
+// FEATS of a feature value, a value matching several UD features
+// is found back from all of them
+var udFeatures = []struct {
+	feature int
+	value   byte
+	feats   string
+}{
+	{FeatureGender, MALE, "Gender=Masc"},
+	{FeatureGender, FEMALE, "Gender=Fem"},
+	{FeatureNumber, SINGULAR, "Number=Sing"},
+	{FeatureNumber, PLURAL, "Number=Plur"},
+	{FeaturePerson, 1, "Person=1"},
+	{FeaturePerson, 2, "Person=2"},
+	{FeaturePerson, 3, "Person=3"},
+	{FeatureTense, IND, "Mood=Ind|VerbForm=Fin"},
+	{FeatureTense, SUBJ, "Mood=Sub|VerbForm=Fin"},
+	{FeatureTense, COND, "Mood=Cnd|VerbForm=Fin"},
+	{FeatureTense, IMP, "Mood=Imp|VerbForm=Fin"},
+	{FeatureTense, INF, "VerbForm=Inf"},
+	{FeatureTense, PPAST, "Tense=Past|VerbForm=Part"},
+	{FeatureTense, GERONDIF, "Tense=Pres|VerbForm=Part"},
+	{FeatureSubcat, DEMONSTRATIVE, "PronType=Dem"},
+	{FeatureSubcat, INDEFINITE, "PronType=Ind"},
+}
+
+const udAbbr = "Abbr=Yes"
+
+func (v *WordVariant) UPOS() string {
+	if v.Tag == NOUN && v.Flags&PROPER != 0 {
+		return "PROPN"
+	}
+	for _, t := range udTags {
+		if t.tag == v.Tag {
+			return t.upos
+		}
+	}
+	return "X"
+}
+
+// FEATS column sorted by feature name, _ when empty
+func (v *WordVariant) FEATS() string {
+	var feats []string
+	for _, f := range udFeatures {
+		if featureValue(v, f.feature) == f.value {
+			feats = append(feats, strings.Split(f.feats, "|")...)
+		}
+	}
+	if v.Tag == ABBR {
+		feats = append(feats, udAbbr)
+	}
+	if len(feats) == 0 {
+		return "_"
+	}
+	sort.Slice(feats, func(i, j int) bool { return strings.ToLower(feats[i]) < strings.ToLower(feats[j]) })
+	return strings.Join(feats, "|")
+}
+
+// variant of the UPOS and FEATS columns, UD features without babble
+// equivalent are ignored
+func ParseUD(upos string, feats string) (v WordVariant, err error) {
+	tag, ok := udUpos[upos]
+	if !ok {
+		return v, fmt.Errorf("unknown UPOS '%s'", upos)
+	}
+	v.Tag = tag
+	if upos == "PROPN" {
+		v.Flags |= PROPER
+	}
+
+	pairs := make(map[string]bool)
+	if feats != "_" && len(feats) > 0 {
+		for _, pair := range strings.Split(feats, "|") {
+			if !strings.Contains(pair, "=") {
+				return v, fmt.Errorf("bad feature '%s' in '%s'", pair, feats)
+			}
+			pairs[pair] = true
+		}
+	}
+	if pairs[udAbbr] {
+		v.Tag = ABBR
+	}
+
+	// the value whose UD features all match, the most specific first
+	matched := make(map[int]int)
+	for _, f := range udFeatures {
+		required := strings.Split(f.feats, "|")
+		all := true
+		for _, pair := range required {
+			all = all && pairs[pair]
+		}
+		if all && len(required) > matched[f.feature] {
+			setFeatureValue(&v, f.feature, f.value)
+			matched[f.feature] = len(required)
+		}
+	}
+	return v, nil
+}
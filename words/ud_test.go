@@ -0,0 +1,75 @@
+package words
+
+import (
+	"testing"
+)
+
+func TestUD(t *testing.T) {
+	expected := []struct {
+		variant WordVariant
+		upos    string
+		feats   string
+	}{
+		{WordVariant{Tag: NOUN, Gender: FEMALE, Number: PLURAL}, "NOUN", "Gender=Fem|Number=Plur"},
+		{WordVariant{Tag: VERB, Tense: SUBJ, Person: 3, Number: SINGULAR}, "VERB", "Mood=Sub|Number=Sing|Person=3|VerbForm=Fin"},
+		{WordVariant{Tag: VERB, Tense: PPAST, Gender: MALE, Number: SINGULAR}, "VERB", "Gender=Masc|Number=Sing|Tense=Past|VerbForm=Part"},
+		{WordVariant{Tag: VERB, Tense: GERONDIF}, "VERB", "Tense=Pres|VerbForm=Part"},
+		{WordVariant{Tag: VERB, Tense: INF}, "VERB", "VerbForm=Inf"},
+		{WordVariant{Tag: NOUN, Flags: PROPER}, "PROPN", "_"},
+		{WordVariant{Tag: PRONOUN, Subcat: DEMONSTRATIVE}, "PRON", "PronType=Dem"},
+		{WordVariant{Tag: ABBR}, "X", "Abbr=Yes"},
+		{WordVariant{Tag: CONJC}, "CCONJ", "_"},
+	}
+	for _, e := range expected {
+		if e.variant.UPOS() != e.upos || e.variant.FEATS() != e.feats {
+			t.Errorf("%+v gives %s %s not %s %s", e.variant, e.variant.UPOS(), e.variant.FEATS(), e.upos, e.feats)
+		}
+		v, err := ParseUD(e.upos, e.feats)
+		if err != nil || !v.Equals(&e.variant) {
+			t.Errorf("%s %s parses to %+v %v", e.upos, e.feats, v, err)
+		}
+	}
+
+	// every tag has a UPOS which maps back to a tag
+	for tag := byte(NOUN); tag <= CR; tag++ {
+		v := WordVariant{Tag: tag}
+		upos := v.UPOS()
+		found := false
+		for _, u := range udTags {
+			found = found || u.tag == tag
+		}
+		if !found {
+			t.Errorf("tag %d without UPOS", tag)
+		}
+		if _, ok := udUpos[upos]; !ok {
+			t.Errorf("UPOS %s of tag %d does not parse", upos, tag)
+		}
+	}
+}
+
+func TestParseUDLoss(t *testing.T) {
+	expected := []struct {
+		upos    string
+		feats   string
+		variant WordVariant
+	}{
+		{"AUX", "Mood=Ind|Number=Sing|Person=3|Tense=Imp|VerbForm=Fin", WordVariant{Tag: VERB, Tense: IND, Person: 3, Number: SINGULAR}},
+		{"DET", "Definite=Def|Gender=Masc|Number=Sing|PronType=Art", WordVariant{Tag: DET, Gender: MALE, Number: SINGULAR}},
+		{"NUM", "_", WordVariant{Tag: DET}},
+		{"PUNCT", "_", WordVariant{}},
+		{"ADJ", "", WordVariant{Tag: ADJ}},
+	}
+	for _, e := range expected {
+		v, err := ParseUD(e.upos, e.feats)
+		if err != nil || !v.Equals(&e.variant) {
+			t.Errorf("%s %s parses to %+v %v", e.upos, e.feats, v, err)
+		}
+	}
+
+	for _, s := range [][2]string{{"FOO", "_"}, {"NOUN", "Gender"}} {
+		_, err := ParseUD(s[0], s[1])
+		if err == nil {
+			t.Errorf("%s %s should not parse", s[0], s[1])
+		}
+	}
+}
@@ -130,6 +130,7 @@ const (
 	POSTPOS             = 0x10
 	COLLECTIVE          = 0x20
 	PROCATDEMONSTRATIVE = 0x40
+	ELIDED              = 0x80 // l' or qu' before a vowel
 )
 
 type WordVariant struct {
@@ -144,14 +145,21 @@ type WordVariant struct {
 
 	Person byte // verb person 1 or 2 or 3
 	Tense  byte // verb tense
+
+	Lemma string // dela lemma of the inflected form
 }
 
 type Word struct {
 	LastLetter *WordLetter
 	Variants   []WordVariant
+
+	form string // words without trie node, from CompactDictionary
 }
 
 func (word *Word) String() string {
+	if word.LastLetter == nil {
+		return word.form
+	}
 	return word.LastLetter.GetWord()
 }
 
@@ -159,7 +167,7 @@ func (word* Word) Description() string {
 	s := "[" + word.String()
 
 	for _, v := range(word.Variants) {
-		s += fmt.Sprintf("[tag %d]", v.Tag)
+		s += "[" + v.String() + "]"
 	}
 
 	s += "]"
@@ -217,6 +225,41 @@ func (rhs *WordVariant) Equals(lhs *WordVariant) bool {
 	if rhs.Tense != lhs.Tense {
 		return false
 	}
+	if rhs.Lemma != lhs.Lemma {
+		return false
+	}
+	return true
+}
+
+// zero fields of v are wildcards
+func (v *WordVariant) Matches(variant *WordVariant) bool {
+	if v.Tag != 0 && v.Tag != variant.Tag {
+		return false
+	}
+	if v.Language != 0 && v.Language != variant.Language {
+		return false
+	}
+	if v.Flags != 0 && v.Flags&variant.Flags != v.Flags {
+		return false
+	}
+	if v.Subcat != 0 && v.Subcat != variant.Subcat {
+		return false
+	}
+	if v.Person != 0 && v.Person != variant.Person {
+		return false
+	}
+	if v.Gender != 0 && v.Gender != variant.Gender {
+		return false
+	}
+	if v.Number != 0 && v.Number != variant.Number {
+		return false
+	}
+	if v.Tense != 0 && v.Tense != variant.Tense {
+		return false
+	}
+	if v.Lemma != "" && v.Lemma != variant.Lemma {
+		return false
+	}
 	return true
 }
 
@@ -253,6 +296,19 @@ func (v *WordVariant) Filter(w *Word) bool {
 
 }
 
+// true when a variant matches filter, a nil filter matches any word
+func (word *Word) HasVariant(filter *WordVariant) bool {
+	if filter == nil {
+		return true
+	}
+	for i := range word.Variants {
+		if filter.Matches(&word.Variants[i]) {
+			return true
+		}
+	}
+	return false
+}
+
 func (word *Word) AddVariants(variants []WordVariant) {
 	for _, v := range variants {
 		found := false
@@ -282,7 +338,7 @@ func (word *Word) VariantsByTag(tag byte, lang byte) []WordVariant {
 func (word *Word) Write(buf io.Writer) {
 	var l int32
 
-	letters := word.LastLetter.GetWord()
+	letters := word.String()
 	l = int32(len(letters))
 	binary.Write(buf, binary.LittleEndian, l)
 	binary.Write(buf, binary.LittleEndian, []byte(letters))
@@ -298,16 +354,29 @@ func (word *Word) Write(buf io.Writer) {
 		binary.Write(buf, binary.LittleEndian, variant.Gender)
 		binary.Write(buf, binary.LittleEndian, variant.Number)
 		binary.Write(buf, binary.LittleEndian, variant.Tense)
+
+		l = int32(len(variant.Lemma))
+		binary.Write(buf, binary.LittleEndian, l)
+		binary.Write(buf, binary.LittleEndian, []byte(variant.Lemma))
 	}
 	return
 }
 
 func (word *Word) Read(buf io.Reader) (s string, err error) {
+	return word.ReadFormat(buf, BinaryVersion)
+}
+
+// reads a record written by the given lm.bin format version
+func (word *Word) ReadFormat(buf io.Reader, version uint32) (s string, err error) {
 	var l int32
 	err = binary.Read(buf, binary.LittleEndian, &l)
 	if err != nil {
 		return
 	}
+	if l < 0 || l > maxRecordLen {
+		err = fmt.Errorf("bad word length %d", l)
+		return
+	}
 	letters := make([]byte, l)
 	err = binary.Read(buf, binary.LittleEndian, &letters)
 	if err != nil {
@@ -320,41 +389,40 @@ func (word *Word) Read(buf io.Reader) (s string, err error) {
 	if err != nil {
 		return
 	}
+	if v < 0 || v > maxRecordLen {
+		err = fmt.Errorf("bad variant count %d for '%s'", v, s)
+		return
+	}
 	var i int32
 	for i = 0; i < v; i++ {
 		word.Variants = append(word.Variants, WordVariant{})
-		err = binary.Read(buf, binary.LittleEndian, &word.Variants[i].Tag)
-		if err != nil {
-			return
-		}
-		err = binary.Read(buf, binary.LittleEndian, &word.Variants[i].Language)
-		if err != nil {
-			return
-		}
-		err = binary.Read(buf, binary.LittleEndian, &word.Variants[i].Flags)
-		if err != nil {
-			return
-		}
-		err = binary.Read(buf, binary.LittleEndian, &word.Variants[i].Subcat)
-		if err != nil {
-			return
+		variant := &word.Variants[len(word.Variants)-1]
+		fields := []*byte{&variant.Tag, &variant.Language, &variant.Flags, &variant.Subcat,
+			&variant.Person, &variant.Gender, &variant.Number, &variant.Tense}
+		for _, field := range fields {
+			err = binary.Read(buf, binary.LittleEndian, field)
+			if err != nil {
+				return
+			}
 		}
-		err = binary.Read(buf, binary.LittleEndian, &word.Variants[i].Person)
-		if err != nil {
-			return
+
+		if version < BinaryVersionLemma {
+			continue
 		}
-		err = binary.Read(buf, binary.LittleEndian, &word.Variants[i].Gender)
+		err = binary.Read(buf, binary.LittleEndian, &l)
 		if err != nil {
 			return
 		}
-		err = binary.Read(buf, binary.LittleEndian, &word.Variants[i].Number)
-		if err != nil {
+		if l < 0 || l > maxRecordLen {
+			err = fmt.Errorf("bad lemma length %d for '%s'", l, s)
 			return
 		}
-		err = binary.Read(buf, binary.LittleEndian, &word.Variants[i].Tense)
+		lemma := make([]byte, l)
+		err = binary.Read(buf, binary.LittleEndian, &lemma)
 		if err != nil {
 			return
 		}
+		variant.Lemma = string(lemma)
 	}
 	return
 }
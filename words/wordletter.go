@@ -1,7 +1,7 @@
 package words
 
 import (
-	"sync"
+	"sort"
 	"unicode/utf8"
 )
 
@@ -12,7 +12,9 @@ type WordLetter struct {
 	Children map[rune]*WordLetter
 }
 
-func (root *WordLetter) AddWord(letters string, word *Word) {
+// returns the word stored in the trie which may differ from word
+// when variants were merged into an existing one
+func (root *WordLetter) AddWord(letters string, word *Word) *Word {
 
 	if root.Children == nil {
 		root.Children = make(map[rune]*WordLetter)
@@ -27,16 +29,17 @@ func (root *WordLetter) AddWord(letters string, word *Word) {
 	}
 
 	if len(letters) > width {
-		root.Children[letter].AddWord(letters[width:], word)
+		return root.Children[letter].AddWord(letters[width:], word)
+	}
+
+	// do not add same word twice
+	if root.Children[letter].Word != nil {
+		root.Children[letter].Word.AddVariants(word.Variants)
 	} else {
-		// do not add same word twice
-		if root.Children[letter].Word != nil {
-			root.Children[letter].Word.AddVariants(word.Variants)
-		} else {
-			root.Children[letter].Word = word
-			word.LastLetter = root.Children[letter]
-		}
+		root.Children[letter].Word = word
+		word.LastLetter = root.Children[letter]
 	}
+	return root.Children[letter].Word
 }
 
 func (root *WordLetter) FindWord(word string) (*Word, bool) {
@@ -103,39 +106,65 @@ func (last *WordLetter) GetWord() string {
 	return string(word)
 }
 
-func (letter *WordLetter) Walk(wordch chan *Word) {
-	if letter.Word != nil {
-		wordch <- letter.Word
-	}
+// bounds of a walk, zero values do not bound it
+type WalkOptions struct {
+	Prefix string // words starting with Prefix
+	Size   int    // words of Size runes
+	Depth  int    // words of at most Depth runes after Prefix
+}
 
-	var wg sync.WaitGroup
-	for _, subletter := range letter.Children {
-		wg.Add(1)
-		go func(sub *WordLetter, wordch chan *Word) {
-			sub.Walk(wordch)
-			wg.Done()
-		}(subletter, wordch)
+// runes left below the prefix for size and depth, negative values are
+// unbounded, ok is false when no word can match
+func (options *WalkOptions) bounds() (size int, depth int, ok bool) {
+	size, depth = -1, -1
+	if options.Size > 0 {
+		size = options.Size - utf8.RuneCountInString(options.Prefix)
+		if size < 0 {
+			return size, depth, false
+		}
 	}
-	wg.Wait()
-
-	return
+	if options.Depth > 0 {
+		depth = options.Depth
+	}
+	return size, depth, true
 }
 
-func (letter *WordLetter) WalkOfSize(runesize int, wordch chan *Word) {
-	if runesize == 0 && letter.Word != nil {
-		wordch <- letter.Word
+func (letter *WordLetter) sortedRunes() []rune {
+	runes := make([]rune, 0, len(letter.Children))
+	for r := range letter.Children {
+		runes = append(runes, r)
 	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return runes
+}
 
-	if runesize > 0 {
-		var wg sync.WaitGroup
-		for _, subletter := range letter.Children {
-			wg.Add(1)
-			go func(size int, sub *WordLetter, wordch chan *Word) {
-				sub.WalkOfSize(size, wordch)
-				wg.Done()
-			}(runesize-1, subletter, wordch)
+// calls fn on the words below letter in rune order until fn returns
+// false, size and depth are the runes left as in WalkOptions.bounds
+func (letter *WordLetter) each(size int, depth int, fn func(*Word) bool) bool {
+	if letter.Word != nil && size <= 0 && !fn(letter.Word) {
+		return false
+	}
+	if size == 0 || depth == 0 {
+		return true
+	}
+	for _, r := range letter.sortedRunes() {
+		if !letter.Children[r].each(size-1, depth-1, fn) {
+			return false
 		}
-		wg.Wait()
 	}
-	return
+	return true
+}
+
+func (letter *WordLetter) Walk(wordch chan *Word) {
+	letter.each(-1, -1, func(w *Word) bool {
+		wordch <- w
+		return true
+	})
+}
+
+func (letter *WordLetter) WalkOfSize(runesize int, wordch chan *Word) {
+	letter.each(runesize, -1, func(w *Word) bool {
+		wordch <- w
+		return true
+	})
 }